@@ -7,10 +7,12 @@ import (
 	"../token"
 )
 
-// Node :
+// Node : Pos and End delimit the source the node was parsed from, End being exclusive
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position
+	End() token.Position
 }
 
 // Statement :
@@ -53,6 +55,28 @@ type ConstStatement struct {
 	Value Expression
 }
 
+// AssignStatement : "name := value", the token being the assignment operator
+type AssignStatement struct {
+	Token token.Token
+	Name  *Identifier
+	Value Expression
+}
+
+// ReadStatement : "read(a, b)" or "readln(a, b)", every argument being a variable
+type ReadStatement struct {
+	Token            token.Token
+	Arguments        []*Identifier
+	RightParenthesis token.Token
+}
+
+// WriteStatement : "write(a + 1, b)" or "writeln(a + 1, b)"
+type WriteStatement struct {
+	Token     token.Token
+	Arguments []Expression
+	// Missing for a "writeln" without parentheses
+	RightParenthesis token.Token
+}
+
 // ExpressionStatement :
 type ExpressionStatement struct {
 	// The first token of the expression
@@ -72,11 +96,20 @@ type RealLiteral struct {
 	Value float64
 }
 
+// StringLiteral : "'abc'", quotes written twice inside the literal being a single one in the value
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
 // PrefixExpression :
 type PrefixExpression struct {
 	Token    token.Token
 	Operator string
 	Right    Expression
+	// Parentheses around the whole expression, if any
+	LeftParenthesis  token.Token
+	RightParenthesis token.Token
 }
 
 // InfixExpression :
@@ -85,12 +118,17 @@ type InfixExpression struct {
 	Left     Expression
 	Operator string
 	Right    Expression
+	// Parentheses around the whole expression, if any
+	LeftParenthesis  token.Token
+	RightParenthesis token.Token
 }
 
 // BlockStatement :
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
+	// The "end" closing the block, missing when the source ended first
+	EndToken token.Token
 }
 
 // ConditionalExpression :
@@ -103,42 +141,45 @@ type ConditionalExpression struct {
 
 // ProcedureLiteral :
 type ProcedureLiteral struct {
-	Token      token.Token
-	Name       string
-	Parameters []*Identifier
-	Body       *BlockStatement
+	Token        token.Token
+	Name         string
+	Parameters   []*Identifier
+	Declarations []Statement
+	Body         *BlockStatement
 }
 
 // CallExpression :
 type CallExpression struct {
-	Token     token.Token
-	Procedure Expression
-	Arguments []Expression
+	Token            token.Token
+	Procedure        Expression
+	Arguments        []Expression
+	RightParenthesis token.Token
 }
 
-// ProgramLiteral :
+// ProgramLiteral : root of a whole LALG source, "program name; declarations procedures begin ... end."
 type ProgramLiteral struct {
-	Token token.Token
-	Name  string
+	Token        token.Token
+	Name         string
+	Declarations []Statement
+	Procedures   []*ProcedureLiteral
+	Body         *BlockStatement
+	Dot          token.Token
 }
 
-// WhileLiteral :
+// WhileLiteral : "while condition do body", the body being a single statement or a block
 type WhileLiteral struct {
 	Token     token.Token
 	Condition Expression
+	Body      Statement
 }
 
-// ForLiteral :
+// ForLiteral : "for variable := from to to do body", the body being a single statement or a block
 type ForLiteral struct {
 	Token    token.Token
-	Variable string
-	Desired  string
-}
-
-// CommentLiteral :
-type CommentLiteral struct {
-	Token    token.Token
-	Comments []string
+	Variable *Identifier
+	From     Expression
+	To       Expression
+	Body     Statement
 }
 
 // statementNode :
@@ -185,9 +226,9 @@ func (vs *VarStatement) String() string {
 	out.WriteString(vs.Name.String())
 	out.WriteString(": ")
 	out.WriteString(vs.Type.Literal)
-	out.WriteString(" := ")
 
 	if nil != vs.Value {
+		out.WriteString(" := ")
 		out.WriteString(vs.Value.String())
 	}
 
@@ -231,6 +272,68 @@ func (cs *ConstStatement) TokenLiteral() string {
 	return cs.Token.Literal
 }
 
+// String :
+func (as *AssignStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(as.Name.String())
+	out.WriteString(" " + as.TokenLiteral() + " ")
+
+	if nil != as.Value {
+		out.WriteString(as.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// statementNode :
+func (as *AssignStatement) statementNode() {}
+
+// TokenLiteral :
+func (as *AssignStatement) TokenLiteral() string {
+	return as.Token.Literal
+}
+
+// String :
+func (rs *ReadStatement) String() string {
+	arguments := []string{}
+
+	for _, a := range rs.Arguments {
+		arguments = append(arguments, a.String())
+	}
+
+	return rs.TokenLiteral() + "(" + strings.Join(arguments, ", ") + ");"
+}
+
+// statementNode :
+func (rs *ReadStatement) statementNode() {}
+
+// TokenLiteral :
+func (rs *ReadStatement) TokenLiteral() string {
+	return rs.Token.Literal
+}
+
+// String :
+func (ws *WriteStatement) String() string {
+	arguments := []string{}
+
+	for _, a := range ws.Arguments {
+		arguments = append(arguments, a.String())
+	}
+
+	return ws.TokenLiteral() + "(" + strings.Join(arguments, ", ") + ");"
+}
+
+// statementNode :
+func (ws *WriteStatement) statementNode() {}
+
+// TokenLiteral :
+func (ws *WriteStatement) TokenLiteral() string {
+	return ws.Token.Literal
+}
+
 // String :
 func (es *ExpressionStatement) String() string {
 	if nil != es.Expression {
@@ -274,6 +377,19 @@ func (rl *RealLiteral) String() string {
 	return rl.Token.Literal
 }
 
+// expressionNode :
+func (sl *StringLiteral) expressionNode() {}
+
+// TokenLiteral :
+func (sl *StringLiteral) TokenLiteral() string {
+	return sl.Token.Literal
+}
+
+// String : quoted, as written in the source
+func (sl *StringLiteral) String() string {
+	return sl.Token.Literal
+}
+
 // expressionNode :
 func (pe *PrefixExpression) expressionNode() {}
 
@@ -288,6 +404,12 @@ func (pe *PrefixExpression) String() string {
 
 	out.WriteString("(")
 	out.WriteString(pe.Operator)
+
+	// keeps word operators apart from their operand
+	if token.NOT == pe.Token.Type {
+		out.WriteString(" ")
+	}
+
 	out.WriteString(pe.Right.String())
 	out.WriteString(")")
 
@@ -318,6 +440,9 @@ func (ie *InfixExpression) String() string {
 // expressionNode :
 func (bs *BlockStatement) expressionNode() {}
 
+// statementNode :
+func (bs *BlockStatement) statementNode() {}
+
 // TokenLiteral :
 func (bs *BlockStatement) TokenLiteral() string {
 	return bs.Token.Literal
@@ -376,14 +501,22 @@ func (pl *ProcedureLiteral) String() string {
 	parameters := []string{}
 
 	for _, p := range pl.Parameters {
-		parameters = append(parameters, p.String())
+		parameters = append(parameters, p.String()+": "+p.Type.Literal)
 	}
 
-	out.WriteString(pl.TokenLiteral())
-	out.WriteString("begin")
+	out.WriteString(pl.TokenLiteral() + " ")
+	out.WriteString(pl.Name)
+	out.WriteString("(")
 	out.WriteString(strings.Join(parameters, ", "))
-	out.WriteString("end ")
+	out.WriteString("); ")
+
+	for _, declaration := range pl.Declarations {
+		out.WriteString(declaration.String() + " ")
+	}
+
+	out.WriteString("begin ")
 	out.WriteString(pl.Body.String())
+	out.WriteString(" end;")
 
 	return out.String()
 }
@@ -424,7 +557,27 @@ func (pl *ProgramLiteral) TokenLiteral() string {
 
 // String :
 func (pl *ProgramLiteral) String() string {
-	return pl.TokenLiteral() + " " + pl.Name + ";"
+	var out bytes.Buffer
+
+	out.WriteString(pl.TokenLiteral() + " " + pl.Name + "; ")
+
+	for _, declaration := range pl.Declarations {
+		out.WriteString(declaration.String() + " ")
+	}
+
+	for _, procedure := range pl.Procedures {
+		out.WriteString(procedure.String() + " ")
+	}
+
+	out.WriteString("begin ")
+
+	if nil != pl.Body {
+		out.WriteString(pl.Body.String())
+	}
+
+	out.WriteString(" end.")
+
+	return out.String()
 }
 
 // expressionNode :
@@ -437,7 +590,7 @@ func (wl *WhileLiteral) TokenLiteral() string {
 
 // String :
 func (wl *WhileLiteral) String() string {
-	return wl.TokenLiteral() + " (" + wl.Condition.String() + ") do "
+	return wl.TokenLiteral() + " " + wl.Condition.String() + " do " + wl.Body.String()
 }
 
 // expressionNode :
@@ -450,18 +603,16 @@ func (fl *ForLiteral) TokenLiteral() string {
 
 // String :
 func (fl *ForLiteral) String() string {
-	return fl.TokenLiteral() + " " + fl.Variable + " to " + fl.Desired + " do "
-}
-
-// expressionNode :
-func (cl *CommentLiteral) expressionNode() {}
+	var out bytes.Buffer
 
-// TokenLiteral :
-func (cl *CommentLiteral) TokenLiteral() string {
-	return cl.Token.Literal
-}
+	out.WriteString(fl.TokenLiteral() + " ")
+	out.WriteString(fl.Variable.String())
+	out.WriteString(" := ")
+	out.WriteString(fl.From.String())
+	out.WriteString(" to ")
+	out.WriteString(fl.To.String())
+	out.WriteString(" do ")
+	out.WriteString(fl.Body.String())
 
-// String :
-func (cl *CommentLiteral) String() string {
-	return "{ " + strings.Join(cl.Comments, " ") + " }"
+	return out.String()
 }
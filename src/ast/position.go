@@ -0,0 +1,329 @@
+package ast
+
+import (
+	"../token"
+)
+
+// present : tells whether the optional token was found in the source
+func present(tok token.Token) bool {
+	return "" != tok.Literal
+}
+
+// endOf : end of the first node given that was parsed, or of the token when none was
+func endOf(tok token.Token, nodes ...Node) token.Position {
+	for _, node := range nodes {
+		if nil != node {
+			return node.End()
+		}
+	}
+
+	return tok.End()
+}
+
+// Pos : start of the first statement, the zero position for an empty program
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+
+	return token.Position{}
+}
+
+// End :
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+
+	return token.Position{}
+}
+
+// Pos :
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Position
+}
+
+// End : the type of a parameter is not part of the identifier
+func (i *Identifier) End() token.Position {
+	return i.Token.End()
+}
+
+// Pos :
+func (vs *VarStatement) Pos() token.Position {
+	return vs.Token.Position
+}
+
+// End : the value when there is one, otherwise the type, the semicolon being left out
+func (vs *VarStatement) End() token.Position {
+	if nil != vs.Value {
+		return vs.Value.End()
+	}
+
+	if present(vs.Type) {
+		return vs.Type.End()
+	}
+
+	if nil != vs.Name {
+		return vs.Name.End()
+	}
+
+	return vs.Token.End()
+}
+
+// Pos :
+func (cs *ConstStatement) Pos() token.Position {
+	return cs.Token.Position
+}
+
+// End : the value, the semicolon being left out
+func (cs *ConstStatement) End() token.Position {
+	if nil != cs.Value {
+		return cs.Value.End()
+	}
+
+	if present(cs.Type) {
+		return cs.Type.End()
+	}
+
+	if nil != cs.Name {
+		return cs.Name.End()
+	}
+
+	return cs.Token.End()
+}
+
+// Pos : the assigned name, which comes before the token
+func (as *AssignStatement) Pos() token.Position {
+	if nil != as.Name {
+		return as.Name.Pos()
+	}
+
+	return as.Token.Position
+}
+
+// End :
+func (as *AssignStatement) End() token.Position {
+	if nil != as.Value {
+		return as.Value.End()
+	}
+
+	return as.Token.End()
+}
+
+// Pos :
+func (rs *ReadStatement) Pos() token.Position {
+	return rs.Token.Position
+}
+
+// End : the closing parenthesis
+func (rs *ReadStatement) End() token.Position {
+	if present(rs.RightParenthesis) {
+		return rs.RightParenthesis.End()
+	}
+
+	return rs.Token.End()
+}
+
+// Pos :
+func (ws *WriteStatement) Pos() token.Position {
+	return ws.Token.Position
+}
+
+// End : the closing parenthesis, or the keyword itself for a "writeln" without arguments
+func (ws *WriteStatement) End() token.Position {
+	if present(ws.RightParenthesis) {
+		return ws.RightParenthesis.End()
+	}
+
+	return ws.Token.End()
+}
+
+// Pos :
+func (es *ExpressionStatement) Pos() token.Position {
+	if nil != es.Expression {
+		return es.Expression.Pos()
+	}
+
+	return es.Token.Position
+}
+
+// End :
+func (es *ExpressionStatement) End() token.Position {
+	return endOf(es.Token, es.Expression)
+}
+
+// Pos :
+func (il *IntegerLiteral) Pos() token.Position {
+	return il.Token.Position
+}
+
+// End :
+func (il *IntegerLiteral) End() token.Position {
+	return il.Token.End()
+}
+
+// Pos :
+func (rl *RealLiteral) Pos() token.Position {
+	return rl.Token.Position
+}
+
+// End :
+func (rl *RealLiteral) End() token.Position {
+	return rl.Token.End()
+}
+
+// Pos :
+func (sl *StringLiteral) Pos() token.Position {
+	return sl.Token.Position
+}
+
+// End : after the closing quote
+func (sl *StringLiteral) End() token.Position {
+	return sl.Token.End()
+}
+
+// Pos : the operator, or the parenthesis opening the expression
+func (pe *PrefixExpression) Pos() token.Position {
+	if present(pe.LeftParenthesis) {
+		return pe.LeftParenthesis.Position
+	}
+
+	return pe.Token.Position
+}
+
+// End :
+func (pe *PrefixExpression) End() token.Position {
+	if present(pe.RightParenthesis) {
+		return pe.RightParenthesis.End()
+	}
+
+	return endOf(pe.Token, pe.Right)
+}
+
+// Pos : the left operand, or the parenthesis opening the expression
+func (ie *InfixExpression) Pos() token.Position {
+	if present(ie.LeftParenthesis) {
+		return ie.LeftParenthesis.Position
+	}
+
+	if nil != ie.Left {
+		return ie.Left.Pos()
+	}
+
+	return ie.Token.Position
+}
+
+// End :
+func (ie *InfixExpression) End() token.Position {
+	if present(ie.RightParenthesis) {
+		return ie.RightParenthesis.End()
+	}
+
+	return endOf(ie.Token, ie.Right)
+}
+
+// Pos : the "begin", "then" or "else" starting the block
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Position
+}
+
+// End : the "end" closing the block, or its last statement when the source ended first
+func (bs *BlockStatement) End() token.Position {
+	if present(bs.EndToken) {
+		return bs.EndToken.End()
+	}
+
+	if len(bs.Statements) > 0 {
+		return bs.Statements[len(bs.Statements)-1].End()
+	}
+
+	return bs.Token.End()
+}
+
+// Pos :
+func (ce *ConditionalExpression) Pos() token.Position {
+	return ce.Token.Position
+}
+
+// End : the "end" of the last branch
+func (ce *ConditionalExpression) End() token.Position {
+	if nil != ce.Alternative {
+		return ce.Alternative.End()
+	}
+
+	if nil != ce.Consequence {
+		return ce.Consequence.End()
+	}
+
+	return endOf(ce.Token, ce.Condition)
+}
+
+// Pos :
+func (pl *ProcedureLiteral) Pos() token.Position {
+	return pl.Token.Position
+}
+
+// End : the "end" of the body, the semicolon being left out
+func (pl *ProcedureLiteral) End() token.Position {
+	if nil != pl.Body {
+		return pl.Body.End()
+	}
+
+	return pl.Token.End()
+}
+
+// Pos : the called procedure, which comes before the token
+func (ce *CallExpression) Pos() token.Position {
+	if nil != ce.Procedure {
+		return ce.Procedure.Pos()
+	}
+
+	return ce.Token.Position
+}
+
+// End : the closing parenthesis
+func (ce *CallExpression) End() token.Position {
+	if present(ce.RightParenthesis) {
+		return ce.RightParenthesis.End()
+	}
+
+	return ce.Token.End()
+}
+
+// Pos :
+func (pl *ProgramLiteral) Pos() token.Position {
+	return pl.Token.Position
+}
+
+// End : the final dot
+func (pl *ProgramLiteral) End() token.Position {
+	if present(pl.Dot) {
+		return pl.Dot.End()
+	}
+
+	if nil != pl.Body {
+		return pl.Body.End()
+	}
+
+	return pl.Token.End()
+}
+
+// Pos :
+func (wl *WhileLiteral) Pos() token.Position {
+	return wl.Token.Position
+}
+
+// End :
+func (wl *WhileLiteral) End() token.Position {
+	return endOf(wl.Token, wl.Body, wl.Condition)
+}
+
+// Pos :
+func (fl *ForLiteral) Pos() token.Position {
+	return fl.Token.Position
+}
+
+// End :
+func (fl *ForLiteral) End() token.Position {
+	return endOf(fl.Token, fl.Body, fl.To, fl.From)
+}
@@ -0,0 +1,85 @@
+package ast_test
+
+import (
+	"testing"
+
+	"../ast"
+)
+
+// TestPositions : the span of every node covers exactly its source, semicolons being left out
+func TestPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		node     string
+		expected string
+	}{
+		{"var a: integer;", "VarStatement var", "var a: integer"},
+		{"var a: integer := 1 + 2;", "VarStatement var", "var a: integer := 1 + 2"},
+		{"const b: real := 2.5;", "ConstStatement const", "const b: real := 2.5"},
+		{"a := b * -c;", "AssignStatement :=", "a := b * -c"},
+		{"a := b * -c;", "PrefixExpression -", "-c"},
+		{"a := (b + 1) * c;", "InfixExpression *", "(b + 1) * c"},
+		{"a := (b + 1) * c;", "InfixExpression +", "(b + 1)"},
+		{"a := ((b));", "Identifier b", "b"},
+		{"write(not (a < 2));", "PrefixExpression not", "not (a < 2)"},
+		{"write('it''s', 1.5e3);", "StringLiteral 'it''s'", "'it''s'"},
+		{"write('it''s', 1.5e3);", "WriteStatement write", "write('it''s', 1.5e3)"},
+		{"writeln;", "WriteStatement writeln", "writeln"},
+		{"readln(a, b);", "ReadStatement readln", "readln(a, b)"},
+		{"p(a, f(1));", "CallExpression (", "p(a, f(1))"},
+		{"p();", "ExpressionStatement p", "p()"},
+		{"if a then b := 1 end else b := 2 end;", "ConditionalExpression if", "if a then b := 1 end else b := 2 end"},
+		{"if a then b := 1 end else b := 2 end;", "BlockStatement then", "then b := 1 end"},
+		{"while a < 1 do begin a := a + 1 end;", "WhileLiteral while", "while a < 1 do begin a := a + 1 end"},
+		{"for i := 1 to n do write(i);", "ForLiteral for", "for i := 1 to n do write(i)"},
+		{"procedure p(x: integer);\nbegin\n\twrite(x)\nend;", "ProcedureLiteral procedure", "procedure p(x: integer);\nbegin\n\twrite(x)\nend"},
+		{"program é;\nbegin\n\twrite('ação')\nend.", "ProgramLiteral program", "program é;\nbegin\n\twrite('ação')\nend."},
+		{"program é;\nbegin\n\twrite('ação')\nend.", "StringLiteral 'ação'", "'ação'"},
+		{"a := 1; b := 2", "Program :=", "a := 1; b := 2"},
+	}
+
+	for _, tt := range tests {
+		found := false
+
+		ast.Inspect(testParse(t, tt.input), func(node ast.Node) bool {
+			if nil == node || found || describe(node) != tt.node {
+				return true
+			}
+
+			found = true
+
+			if span := tt.input[node.Pos().Offset:node.End().Offset]; span != tt.expected {
+				t.Errorf("wrong span of %s in %q, expected=%q, got=%q", tt.node, tt.input, tt.expected, span)
+			}
+
+			return true
+		})
+
+		if !found {
+			t.Errorf("no %s in %q", tt.node, tt.input)
+		}
+	}
+}
+
+// TestPositionLines : lines and columns, in runes, agree with the offsets
+func TestPositionLines(t *testing.T) {
+	program := testParse(t, "var s: integer;\nwhile s < 1 do begin\n\ts := 'ç' + s\nend")
+
+	loop, ok := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.WhileLiteral)
+
+	if !ok {
+		t.Fatalf("program.Statements[1] is not a while loop, got=%s", program.Statements[1])
+	}
+
+	block := loop.Body.(*ast.BlockStatement)
+
+	value := block.Statements[0].(*ast.AssignStatement).Value
+
+	if "3:7" != value.Pos().String() || "3:14" != value.End().String() {
+		t.Errorf("wrong span, expected=3:7-3:14, got=%s-%s", value.Pos(), value.End())
+	}
+
+	if "2:16" != block.Pos().String() || "4:4" != block.End().String() {
+		t.Errorf("wrong block span, expected=2:16-4:4, got=%s-%s", block.Pos(), block.End())
+	}
+}
@@ -0,0 +1,173 @@
+package ast
+
+import (
+	"fmt"
+)
+
+// Visitor : Visit is called for every node found by Walk; when the visitor it returns is not nil, Walk visits each
+// child of the node with it, followed by a call of its Visit with nil
+type Visitor interface {
+	Visit(node Node) Visitor
+}
+
+// inspector : turns a function into a Visitor, used by Inspect
+type inspector func(Node) bool
+
+// Visit :
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+
+	return nil
+}
+
+// walkStatements :
+func walkStatements(v Visitor, statements []Statement) {
+	for _, statement := range statements {
+		Walk(v, statement)
+	}
+}
+
+// walkExpressions :
+func walkExpressions(v Visitor, expressions []Expression) {
+	for _, expression := range expressions {
+		Walk(v, expression)
+	}
+}
+
+// walkIdentifiers :
+func walkIdentifiers(v Visitor, identifiers []*Identifier) {
+	for _, identifier := range identifiers {
+		Walk(v, identifier)
+	}
+}
+
+// Walk : depth first traversal of the tree in source order, children missing from partially parsed code are skipped
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); nil == v {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(v, n.Statements)
+	case *Identifier, *IntegerLiteral, *RealLiteral, *StringLiteral:
+		// leaves, types are tokens rather than nodes
+	case *VarStatement:
+		if nil != n.Name {
+			Walk(v, n.Name)
+		}
+
+		if nil != n.Value {
+			Walk(v, n.Value)
+		}
+	case *ConstStatement:
+		if nil != n.Name {
+			Walk(v, n.Name)
+		}
+
+		if nil != n.Value {
+			Walk(v, n.Value)
+		}
+	case *AssignStatement:
+		if nil != n.Name {
+			Walk(v, n.Name)
+		}
+
+		if nil != n.Value {
+			Walk(v, n.Value)
+		}
+	case *ReadStatement:
+		walkIdentifiers(v, n.Arguments)
+	case *WriteStatement:
+		walkExpressions(v, n.Arguments)
+	case *ExpressionStatement:
+		if nil != n.Expression {
+			Walk(v, n.Expression)
+		}
+	case *PrefixExpression:
+		if nil != n.Right {
+			Walk(v, n.Right)
+		}
+	case *InfixExpression:
+		if nil != n.Left {
+			Walk(v, n.Left)
+		}
+
+		if nil != n.Right {
+			Walk(v, n.Right)
+		}
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+	case *ConditionalExpression:
+		if nil != n.Condition {
+			Walk(v, n.Condition)
+		}
+
+		if nil != n.Consequence {
+			Walk(v, n.Consequence)
+		}
+
+		if nil != n.Alternative {
+			Walk(v, n.Alternative)
+		}
+	case *ProcedureLiteral:
+		walkIdentifiers(v, n.Parameters)
+		walkStatements(v, n.Declarations)
+
+		if nil != n.Body {
+			Walk(v, n.Body)
+		}
+	case *CallExpression:
+		if nil != n.Procedure {
+			Walk(v, n.Procedure)
+		}
+
+		walkExpressions(v, n.Arguments)
+	case *ProgramLiteral:
+		walkStatements(v, n.Declarations)
+
+		for _, procedure := range n.Procedures {
+			Walk(v, procedure)
+		}
+
+		if nil != n.Body {
+			Walk(v, n.Body)
+		}
+	case *WhileLiteral:
+		if nil != n.Condition {
+			Walk(v, n.Condition)
+		}
+
+		if nil != n.Body {
+			Walk(v, n.Body)
+		}
+	case *ForLiteral:
+		if nil != n.Variable {
+			Walk(v, n.Variable)
+		}
+
+		if nil != n.From {
+			Walk(v, n.From)
+		}
+
+		if nil != n.To {
+			Walk(v, n.To)
+		}
+
+		if nil != n.Body {
+			Walk(v, n.Body)
+		}
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+// Inspect : calls f for every node in source order, the children of a node being skipped when f returns false for it;
+// once the children were visited f is called with nil
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
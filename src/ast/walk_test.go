@@ -0,0 +1,172 @@
+package ast_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"../ast"
+	"../lexer"
+	"../parser"
+)
+
+const walked = `program walk;
+var a: integer := 1;
+const b: real := 2.5;
+procedure p(x: integer, y: real);
+	var z: integer;
+	begin
+		read(z);
+		if x > z then writeln('big', -y) end else a := z end
+	end;
+begin
+	for a := 1 to 3 do p(a, b);
+	while not (a = 0) do a := a - 1
+end.`
+
+// testParse :
+func testParse(t *testing.T, input string) *ast.Program {
+	p := parser.InitializeParser(lexer.InitializeLexer(input))
+	program := p.ParseProgram()
+
+	if 0 != len(p.Errors()) {
+		t.Fatalf("parser errors: %q", p.Errors())
+	}
+
+	return program
+}
+
+// describe : type and token of a node, as in "Identifier a"
+func describe(node ast.Node) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.") + " " + node.TokenLiteral()
+}
+
+// TestInspect : every node is found in source order
+func TestInspect(t *testing.T) {
+	expected := []string{
+		"Program program",
+		"ExpressionStatement program",
+		"ProgramLiteral program",
+		"VarStatement var", "Identifier a", "IntegerLiteral 1",
+		"ConstStatement const", "Identifier b", "RealLiteral 2.5",
+		"ProcedureLiteral procedure", "Identifier x", "Identifier y",
+		"VarStatement var", "Identifier z",
+		"BlockStatement begin",
+		"ReadStatement read", "Identifier z",
+		"ExpressionStatement if",
+		"ConditionalExpression if", "InfixExpression >", "Identifier x", "Identifier z",
+		"BlockStatement then", "WriteStatement writeln", "StringLiteral 'big'", "PrefixExpression -", "Identifier y",
+		"BlockStatement else", "AssignStatement :=", "Identifier a", "Identifier z",
+		"BlockStatement begin",
+		"ExpressionStatement for",
+		"ForLiteral for", "Identifier a", "IntegerLiteral 1", "IntegerLiteral 3",
+		"ExpressionStatement p", "CallExpression (", "Identifier p", "Identifier a", "Identifier b",
+		"ExpressionStatement while",
+		"WhileLiteral while", "PrefixExpression not", "InfixExpression =", "Identifier a", "IntegerLiteral 0",
+		"AssignStatement :=", "Identifier a", "InfixExpression -", "Identifier a", "IntegerLiteral 1",
+	}
+
+	visited := []string{}
+	depth := 0
+
+	ast.Inspect(testParse(t, walked), func(node ast.Node) bool {
+		if nil == node {
+			depth--
+		} else {
+			depth++
+			visited = append(visited, describe(node))
+		}
+
+		return true
+	})
+
+	if strings.Join(visited, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong nodes, expected=\n%s\ngot=\n%s", strings.Join(expected, "\n"), strings.Join(visited, "\n"))
+	}
+
+	if 0 != depth {
+		t.Errorf("every visited node should end with a nil, got=%d unbalanced", depth)
+	}
+}
+
+// TestInspectPruning : returning false skips the children of the node
+func TestInspectPruning(t *testing.T) {
+	identifiers := []string{}
+
+	ast.Inspect(testParse(t, walked), func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.ProcedureLiteral:
+			return false
+		case *ast.Identifier:
+			identifiers = append(identifiers, node.Value)
+		}
+
+		return true
+	})
+
+	if "a b a p a b a a a" != strings.Join(identifiers, " ") {
+		t.Errorf("procedure p should be skipped, got=%q", identifiers)
+	}
+}
+
+// counter : visitor counting the nodes of each type, stopping at the calls
+type counter map[string]int
+
+// Visit :
+func (c counter) Visit(node ast.Node) ast.Visitor {
+	if nil == node {
+		return nil
+	}
+
+	c[fmt.Sprintf("%T", node)]++
+
+	if _, ok := node.(*ast.CallExpression); ok {
+		return nil
+	}
+
+	return c
+}
+
+// TestWalk :
+func TestWalk(t *testing.T) {
+	c := counter{}
+
+	ast.Walk(c, testParse(t, walked))
+
+	tests := []struct {
+		name     string
+		expected int
+	}{
+		{"*ast.Identifier", 15},
+		{"*ast.BlockStatement", 4},
+		{"*ast.CallExpression", 1},
+		{"*ast.ExpressionStatement", 5},
+	}
+
+	for _, tt := range tests {
+		if c[tt.name] != tt.expected {
+			t.Errorf("wrong number of %s, expected=%d, got=%d", tt.name, tt.expected, c[tt.name])
+		}
+	}
+}
+
+// TestWalkPartialTree : missing children of code that did not parse are skipped
+func TestWalkPartialTree(t *testing.T) {
+	nodes := 0
+
+	ast.Inspect(&ast.Program{Statements: []ast.Statement{
+		&ast.VarStatement{Name: &ast.Identifier{Value: "a"}},
+		&ast.ExpressionStatement{Expression: &ast.ConditionalExpression{}},
+		&ast.ExpressionStatement{Expression: &ast.ForLiteral{}},
+	}}, func(node ast.Node) bool {
+		if nil != node {
+			nodes++
+		}
+
+		return true
+	})
+
+	if 7 != nodes {
+		t.Errorf("wrong number of nodes, expected=%d, got=%d", 7, nodes)
+	}
+}
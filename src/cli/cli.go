@@ -0,0 +1,518 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"../ast"
+	"../compiler"
+	"../diagnostic"
+	"../dump"
+	"../evaluator"
+	"../lexer"
+	"../mepa"
+	"../object"
+	"../parser"
+	"../printer"
+	"../repl"
+	"../semantic"
+	"../vm"
+)
+
+// Exit codes, so scripts can tell a bad program from a bad command line
+const (
+	EXIT_OK     = 0
+	EXIT_ERRORS = 1
+	EXIT_USAGE  = 2
+)
+
+// STDIN : file name that stands for the standard input
+const STDIN = "-"
+
+// Command : a subcommand of the lalg executable
+type Command struct {
+	Name        string
+	Arguments   string
+	Description string
+	run         func(c *CLI, flags *flag.FlagSet, args []string) int
+	flags       func(c *CLI, flags *flag.FlagSet)
+}
+
+// CLI : a single invocation, with the streams it reads from and writes to
+type CLI struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	// Options of the subcommands, filled by their flag sets
+	backend      string
+	format       string
+	integerWidth integerWidth
+	checkFormat  bool
+}
+
+// integerWidth : flag accepting only the widths the lexer knows about
+type integerWidth int
+
+// String :
+func (w *integerWidth) String() string {
+	return strconv.Itoa(int(*w))
+}
+
+// Set :
+func (w *integerWidth) Set(value string) error {
+	switch value {
+	case "16", "32", "64":
+		width, _ := strconv.Atoi(value)
+		*w = integerWidth(width)
+
+		return nil
+	}
+
+	return fmt.Errorf("must be 16, 32 or 64")
+}
+
+var commands []*Command
+
+// lexerFlags : options shared by every command reading source code
+func lexerFlags(c *CLI, flags *flag.FlagSet) {
+	c.integerWidth = lexer.INTEGER_WIDTH
+	flags.Var(&c.integerWidth, "int-width", "bits of an integer, larger literals being errors and larger results runtime errors")
+}
+
+func init() {
+	commands = []*Command{
+		{"lex", "[-format pair|table|json] FILE...", "prints the tokens of the files", (*CLI).lex, func(c *CLI, flags *flag.FlagSet) {
+			flags.StringVar(&c.format, "format", string(dump.TABLE_FORMAT), "prints <string> - <token> pairs (pair), aligned columns (table) or JSON lines (json)")
+			lexerFlags(c, flags)
+		}},
+		{"parse", "FILE...", "prints the syntax tree of the files", (*CLI).parse, lexerFlags},
+		{"check", "FILE...", "reports the syntax and semantic errors of the files", (*CLI).check, lexerFlags},
+		{"fmt", "[-check] FILE...", "prints the files in the canonical layout", (*CLI).formatSource, func(c *CLI, flags *flag.FlagSet) {
+			flags.BoolVar(&c.checkFormat, "check", false, "lists the files that are not formatted instead of printing them")
+			lexerFlags(c, flags)
+		}},
+		{"run", "[-backend eval|vm|mepa] FILE", "checks and executes a program", (*CLI).run, func(c *CLI, flags *flag.FlagSet) {
+			flags.StringVar(&c.backend, "backend", "eval", "executes with the tree-walking evaluator (eval), the virtual machine (vm) or the MEPA machine (mepa)")
+			lexerFlags(c, flags)
+		}},
+		{"repl", "", "starts the interactive interpreter", (*CLI).repl, nil},
+	}
+}
+
+// usage :
+func (c *CLI) usage(out io.Writer) {
+	fmt.Fprintf(out, "usage: lalg COMMAND [ARGUMENTS]\n\ncommands:\n")
+
+	for _, command := range commands {
+		fmt.Fprintf(out, "  %-7s %-30s %s\n", command.Name, command.Arguments, command.Description)
+	}
+
+	fmt.Fprintf(out, "\nthe file name %s reads the source from the standard input\n", STDIN)
+}
+
+// Run : executes the command line, args not including the program name, returning the exit code
+func Run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	c := &CLI{
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+
+	if 0 == len(args) {
+		c.usage(stderr)
+
+		return EXIT_USAGE
+	}
+
+	if "help" == args[0] || "-h" == args[0] || "--help" == args[0] {
+		c.usage(stdout)
+
+		return EXIT_OK
+	}
+
+	for _, command := range commands {
+		if command.Name != args[0] {
+			continue
+		}
+
+		flags := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+		flags.SetOutput(stderr)
+		flags.Usage = func() {
+			fmt.Fprintf(stderr, "usage: lalg %s %s\n", command.Name, command.Arguments)
+			flags.PrintDefaults()
+		}
+
+		if nil != command.flags {
+			command.flags(c, flags)
+		}
+
+		if err := flags.Parse(args[1:]); nil != err {
+			if flag.ErrHelp == err {
+				return EXIT_OK
+			}
+
+			return EXIT_USAGE
+		}
+
+		return command.run(c, flags, flags.Args())
+	}
+
+	fmt.Fprintf(stderr, "lalg: unknown command %q\n\n", args[0])
+	c.usage(stderr)
+
+	return EXIT_USAGE
+}
+
+// open : the file, or the standard input, which is left open
+func (c *CLI) open(name string) (io.ReadCloser, bool) {
+	if STDIN == name {
+		return ioutil.NopCloser(c.stdin), true
+	}
+
+	file, err := os.Open(name)
+
+	if nil != err {
+		fmt.Fprintf(c.stderr, "lalg: %s\n", err)
+
+		return nil, false
+	}
+
+	return file, true
+}
+
+// read : whole contents of the file, or of the standard input
+func (c *CLI) read(name string) (string, bool) {
+	in, ok := c.open(name)
+
+	if !ok {
+		return "", false
+	}
+
+	defer in.Close()
+
+	source, err := ioutil.ReadAll(in)
+
+	if nil != err {
+		fmt.Fprintf(c.stderr, "lalg: %s: %s\n", name, err)
+
+		return "", false
+	}
+
+	return string(source), true
+}
+
+// report : diagnostics prefixed by the name of the file they belong to and followed by the source they refer to,
+// telling whether any is an error
+func (c *CLI) report(name string, source string, diagnostics diagnostic.List) bool {
+	for _, d := range diagnostics {
+		fmt.Fprintf(c.stderr, "%s:%s\n", name, d.Format())
+
+		if underline := diagnostic.Underline(source, d); "" != underline {
+			fmt.Fprintln(c.stderr, underline)
+		}
+	}
+
+	return diagnostics.HasErrors()
+}
+
+// requireFiles : usage error when no file is given
+func (c *CLI) requireFiles(flags *flag.FlagSet, args []string) bool {
+	if 0 != len(args) {
+		return true
+	}
+
+	fmt.Fprintf(c.stderr, "lalg %s: no input files\n", flags.Name())
+	flags.Usage()
+
+	return false
+}
+
+// forEachFile : runs action on the source of every file, the exit code being the worst one
+func (c *CLI) forEachFile(args []string, action func(name string, source string) int) int {
+	status := EXIT_OK
+
+	for _, name := range args {
+		source, ok := c.read(name)
+
+		if !ok {
+			status = EXIT_ERRORS
+
+			continue
+		}
+
+		if result := action(name, source); result > status {
+			status = result
+		}
+	}
+
+	return status
+}
+
+// lexerOptions : options given in the command line
+func (c *CLI) lexerOptions() lexer.Options {
+	options := lexer.DefaultOptions()
+	options.IntegerWidth = int(c.integerWidth)
+
+	return options
+}
+
+// lexer : lexer for the source with the options given in the command line
+func (c *CLI) lexer(source string) *lexer.Lexer {
+	return lexer.InitializeLexerWithOptions(source, c.lexerOptions())
+}
+
+// parseFile : the program and whether it parsed without errors
+func (c *CLI) parseFile(name string, source string) (*ast.Program, bool) {
+	p := parser.InitializeParser(c.lexer(source))
+	program := p.ParseProgram()
+
+	return program, !c.report(name, source, p.Diagnostics())
+}
+
+// checkFile : the program and whether it is free of syntax and semantic errors
+func (c *CLI) checkFile(name string, source string) (*ast.Program, bool) {
+	program, ok := c.parseFile(name, source)
+
+	if !ok {
+		return program, false
+	}
+
+	a := semantic.InitializeAnalyzer()
+	a.Analyze(program)
+
+	return program, !c.report(name, source, a.Diagnostics())
+}
+
+// isFormat :
+func isFormat(format dump.Format) bool {
+	for _, supported := range dump.FORMATS {
+		if supported == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lex :
+func (c *CLI) lex(flags *flag.FlagSet, args []string) int {
+	if !c.requireFiles(flags, args) {
+		return EXIT_USAGE
+	}
+
+	if !isFormat(dump.Format(c.format)) {
+		fmt.Fprintf(c.stderr, "lalg lex: unknown format %q\n", c.format)
+		flags.Usage()
+
+		return EXIT_USAGE
+	}
+
+	status := EXIT_OK
+
+	// the tokens are written while the file is read, the source is never held in memory as a whole
+	for _, name := range args {
+		in, ok := c.open(name)
+
+		if !ok {
+			status = EXIT_ERRORS
+
+			continue
+		}
+
+		l := lexer.InitializeLexerFromReaderWithOptions(in, c.lexerOptions())
+		err := dump.Dump(l, dump.Format(c.format), c.stdout)
+		in.Close()
+
+		if nil == err {
+			err = l.Err()
+		}
+
+		if nil != err {
+			fmt.Fprintf(c.stderr, "lalg: %s: %s\n", name, err)
+			status = EXIT_ERRORS
+		}
+
+		// without the source the diagnostics are not underlined, the dump already shows the tokens they refer to
+		if c.report(name, "", l.Diagnostics()) {
+			status = EXIT_ERRORS
+		}
+	}
+
+	return status
+}
+
+// parse :
+func (c *CLI) parse(flags *flag.FlagSet, args []string) int {
+	if !c.requireFiles(flags, args) {
+		return EXIT_USAGE
+	}
+
+	return c.forEachFile(args, func(name string, source string) int {
+		program, ok := c.parseFile(name, source)
+
+		for _, statement := range program.Statements {
+			fmt.Fprintln(c.stdout, statement.String())
+		}
+
+		if !ok {
+			return EXIT_ERRORS
+		}
+
+		return EXIT_OK
+	})
+}
+
+// check :
+func (c *CLI) check(flags *flag.FlagSet, args []string) int {
+	if !c.requireFiles(flags, args) {
+		return EXIT_USAGE
+	}
+
+	return c.forEachFile(args, func(name string, source string) int {
+		if _, ok := c.checkFile(name, source); !ok {
+			return EXIT_ERRORS
+		}
+
+		return EXIT_OK
+	})
+}
+
+// formatSource : the canonical source of every file, or the names of those that differ from it
+func (c *CLI) formatSource(flags *flag.FlagSet, args []string) int {
+	if !c.requireFiles(flags, args) {
+		return EXIT_USAGE
+	}
+
+	return c.forEachFile(args, func(name string, source string) int {
+		options := c.lexerOptions()
+		options.KeepComments = true
+
+		formatted, diagnostics := printer.Format(lexer.InitializeLexerWithOptions(source, options))
+
+		if c.report(name, source, diagnostics) {
+			return EXIT_ERRORS
+		}
+
+		if !c.checkFormat {
+			fmt.Fprint(c.stdout, formatted)
+
+			return EXIT_OK
+		}
+
+		if formatted != source {
+			fmt.Fprintln(c.stdout, name)
+
+			return EXIT_ERRORS
+		}
+
+		return EXIT_OK
+	})
+}
+
+// run : read statements take their input from the standard input, so the source cannot come from there too
+func (c *CLI) run(flags *flag.FlagSet, args []string) int {
+	if 1 != len(args) || STDIN == args[0] {
+		fmt.Fprintf(c.stderr, "lalg run: expected a single source file\n")
+		flags.Usage()
+
+		return EXIT_USAGE
+	}
+
+	execute, ok := map[string]func(*ast.Program) error{
+		"eval": c.evaluate,
+		"vm":   c.virtualMachine,
+		"mepa": c.mepaMachine,
+	}[c.backend]
+
+	if !ok {
+		fmt.Fprintf(c.stderr, "lalg run: unknown backend %q\n", c.backend)
+		flags.Usage()
+
+		return EXIT_USAGE
+	}
+
+	return c.forEachFile(args, func(name string, source string) int {
+		program, ok := c.checkFile(name, source)
+
+		if !ok {
+			return EXIT_ERRORS
+		}
+
+		if err := execute(program); nil != err {
+			fmt.Fprintf(c.stderr, "%s: %s\n", name, err)
+
+			return EXIT_ERRORS
+		}
+
+		return EXIT_OK
+	})
+}
+
+// evaluate :
+func (c *CLI) evaluate(program *ast.Program) error {
+	e := evaluator.InitializeEvaluatorWithIntegerWidth(c.stdin, c.stdout, int(c.integerWidth))
+
+	if failure, ok := e.Eval(program, object.InitializeEnvironment()).(*object.Error); ok {
+		return failure
+	}
+
+	return nil
+}
+
+// virtualMachine :
+func (c *CLI) virtualMachine(program *ast.Program) error {
+	bytecode := compiler.InitializeCompiler()
+
+	if err := bytecode.Compile(program); nil != err {
+		return err
+	}
+
+	return vm.InitializeVMWithIntegerWidth(bytecode.Bytecode(), c.stdin, c.stdout, int(c.integerWidth)).Run()
+}
+
+// mepaMachine : generates the MEPA code and runs it right away
+func (c *CLI) mepaMachine(program *ast.Program) error {
+	code, err := mepa.InitializeGenerator().Generate(program)
+
+	if nil != err {
+		return err
+	}
+
+	machine, err := mepa.InitializeMachineWithIntegerWidth(code, c.stdin, c.stdout, int(c.integerWidth))
+
+	if nil != err {
+		return err
+	}
+
+	return machine.Run()
+}
+
+// repl : greets whoever is logged in and reads statements until the input ends
+func (c *CLI) repl(flags *flag.FlagSet, args []string) int {
+	if 0 != len(args) {
+		fmt.Fprintf(c.stderr, "lalg repl: unexpected arguments %s\n", strings.Join(args, " "))
+		flags.Usage()
+
+		return EXIT_USAGE
+	}
+
+	name := "there"
+
+	if current, err := user.Current(); nil == err {
+		name = current.Username
+	}
+
+	fmt.Fprintf(c.stdout, "Hello %s! This is LALG programming language!\n", name)
+	fmt.Fprintf(c.stdout, "Fell free to type in commands\n")
+	fmt.Fprintf(c.stdout, "To exit, just type Ctrl + C\n")
+
+	repl.Start(c.stdin, c.stdout)
+
+	return EXIT_OK
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const program = `program sum;
+var a: integer;
+var b: integer;
+begin
+	read(a, b);
+	writeln(a + b);
+	writeln(a / b)
+end.
+`
+
+// writeFiles : creates the sources in a temporary directory, returning it
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "lalg")
+
+	if nil != err {
+		t.Fatalf("could not create the temporary directory: %s", err)
+	}
+
+	for name, source := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(source), 0644); nil != err {
+			t.Fatalf("could not write %s: %s", name, err)
+		}
+	}
+
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"sum.lalg":         program,
+		"illegal.lalg":     "var a: integer := 1 @ 2;",
+		"syntax.lalg":      "var a integer;",
+		"semantic.lalg":    "var a: integer;\na := 2.5;",
+		"runtime.lalg":     "var a: integer := 1 div 0;",
+		"overflow.lalg":    "var a: integer := 32767;\na := a + 1;",
+		"largest.lalg":     "var i: integer;\nvar n: integer;\nfor i := 32766 to 32767 do n := n + 1;\nwriteln(n, i)",
+		"unformatted.lalg": "var a:integer;a:=( a+1 )",
+		"formatted.lalg":   "var a: integer;\na := a + 1;\n",
+	})
+
+	defer os.RemoveAll(dir)
+
+	file := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	tests := []struct {
+		args           []string
+		stdin          string
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{[]string{}, "", EXIT_USAGE, "", "usage: lalg COMMAND"},
+		{[]string{"help"}, "", EXIT_OK, "usage: lalg COMMAND", ""},
+		{[]string{"build"}, "", EXIT_USAGE, "", `unknown command "build"`},
+		{[]string{"lex"}, "", EXIT_USAGE, "", "lalg lex: no input files"},
+		{[]string{"lex", file("sum.lalg")}, "", EXIT_OK, "1:9        IDENTIFIER           id                     sum\n", ""},
+		{[]string{"lex", "-format", "pair", file("sum.lalg")}, "", EXIT_OK, "program - program\nsum - id\n; - simb_ponto_virgula\n", ""},
+		{[]string{"lex", "-format", "json", "-"}, "a", EXIT_OK, `{"type":"IDENTIFIER","class":"id","literal":"a","line":1,"column":1,"offset":0}`, ""},
+		{[]string{"lex", "-format", "yaml", file("sum.lalg")}, "", EXIT_USAGE, "", `unknown format "yaml"`},
+		{[]string{"lex", "-format", "pair", file("illegal.lalg")}, "", EXIT_ERRORS, "@ - erro - simbolo nao pertencente a linguagem\n", "illegal.lalg:1:21: error[L001]: illegal character \"@\""},
+		{[]string{"lex", file("missing.lalg")}, "", EXIT_ERRORS, "", "missing.lalg: no such file or directory"},
+		{[]string{"parse", file("sum.lalg")}, "", EXIT_OK, "program sum;", ""},
+		{[]string{"parse", file("syntax.lalg")}, "", EXIT_ERRORS, "", "syntax.lalg:1:7: error[P001]: Expected next token to be :"},
+		{[]string{"check", file("sum.lalg"), file("semantic.lalg")}, "", EXIT_ERRORS, "", "semantic.lalg:2:6: error[S006]: cannot use real value as integer in assignment to a\na := 2.5;\n     ^~~\n"},
+		{[]string{"check", "-"}, program, EXIT_OK, "", ""},
+		{[]string{"check", "-int-width", "16", "-"}, "var a: integer := 40000;", EXIT_ERRORS, "", "-:1:19: error[L005]: number out of range \"40000\", the largest 16-bit integer is 32767"},
+		{[]string{"check", "-int-width", "32", "-"}, "var a: integer := 40000;", EXIT_OK, "", ""},
+		{[]string{"check", "-int-width", "16", "-"}, "var a: integer := -32768;", EXIT_OK, "", ""},
+		{[]string{"check", "-int-width", "16", "-"}, "var a: integer; a := a - 32768;", EXIT_ERRORS, "", "-:1:26: error[L005]: number out of range \"32768\""},
+		{[]string{"check", "-int-width", "8", "-"}, "", EXIT_USAGE, "", "must be 16, 32 or 64"},
+		{[]string{"lex", "-int-width", "16", "-format", "pair", "-"}, "65536", EXIT_ERRORS, "65536 - erro - numero fora do intervalo\n", "error[L005]"},
+		{[]string{"fmt"}, "", EXIT_USAGE, "", "lalg fmt: no input files"},
+		{[]string{"fmt", file("unformatted.lalg")}, "", EXIT_OK, "var a: integer;\na := a + 1;\n", ""},
+		{[]string{"fmt", "-"}, "writeln( 'a' ) {c}", EXIT_OK, "writeln('a'); {c}\n", ""},
+		{[]string{"fmt", file("syntax.lalg")}, "", EXIT_ERRORS, "", "syntax.lalg:1:7: error[P001]"},
+		{[]string{"fmt", "-check", file("formatted.lalg"), file("unformatted.lalg")}, "", EXIT_ERRORS, "unformatted.lalg\n", ""},
+		{[]string{"fmt", "-check", file("formatted.lalg")}, "", EXIT_OK, "", ""},
+		{[]string{"run", file("sum.lalg")}, "3 4", EXIT_OK, "7\n0.75\n", ""},
+		{[]string{"run", "-backend", "vm", file("sum.lalg")}, "3 4", EXIT_OK, "7\n0.75\n", ""},
+		{[]string{"run", "-backend", "mepa", file("sum.lalg")}, "3 4", EXIT_OK, "7\n0.75\n", ""},
+		{[]string{"run", "-backend", "jit", file("sum.lalg")}, "", EXIT_USAGE, "", `unknown backend "jit"`},
+		{[]string{"run", file("semantic.lalg")}, "", EXIT_ERRORS, "", "error[S006]"},
+		{[]string{"run", file("runtime.lalg")}, "", EXIT_ERRORS, "", "runtime.lalg: 1:21: runtime error: division by zero"},
+		{[]string{"run", "-int-width", "16", file("overflow.lalg")}, "", EXIT_ERRORS, "", "overflow.lalg: 2:8: runtime error: integer overflow, 32767 + 1 does not fit in 16 bits"},
+		{[]string{"run", "-int-width", "16", "-backend", "vm", file("overflow.lalg")}, "", EXIT_ERRORS, "", "overflow.lalg: 2:8: runtime error: integer overflow, 32767 + 1 does not fit in 16 bits"},
+		{[]string{"run", "-int-width", "16", "-backend", "mepa", file("overflow.lalg")}, "", EXIT_ERRORS, "", "(SOMA): integer overflow, 32767 + 1 does not fit in 16 bits"},
+		{[]string{"run", "-int-width", "32", "-backend", "mepa", file("overflow.lalg")}, "", EXIT_OK, "", ""},
+		{[]string{"run", "-int-width", "16", file("largest.lalg")}, "", EXIT_OK, "232767\n", ""},
+		{[]string{"run", "-int-width", "16", "-backend", "vm", file("largest.lalg")}, "", EXIT_OK, "232767\n", ""},
+		{[]string{"run", "-int-width", "16", "-backend", "mepa", file("largest.lalg")}, "", EXIT_OK, "232767\n", ""},
+		{[]string{"run", "-int-width", "16", file("sum.lalg")}, "40000 1", EXIT_ERRORS, "", ": \"40000\" does not fit in 16 bits"},
+		{[]string{"run", "-int-width", "16", "-backend", "vm", file("sum.lalg")}, "40000 1", EXIT_ERRORS, "", ": \"40000\" does not fit in 16 bits"},
+		{[]string{"run", "-int-width", "16", "-backend", "mepa", file("sum.lalg")}, "40000 1", EXIT_ERRORS, "", ": \"40000\" does not fit in 16 bits"},
+		{[]string{"run", "-int-width", "16", "-backend", "mepa", file("sum.lalg")}, "-32768 1", EXIT_OK, "-32767\n-32768.0\n", ""},
+		{[]string{"run", "-"}, program, EXIT_USAGE, "", "expected a single source file"},
+		{[]string{"repl"}, "var a: integer := 2;\na * 3\n", EXIT_OK, ">> >> 6\n", ""},
+		{[]string{"repl", file("sum.lalg")}, "", EXIT_USAGE, "", "unexpected arguments"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+
+		code := Run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+		if code != tt.expectedCode {
+			t.Errorf("wrong exit code for %q, expected=%d, got=%d (stderr=%q)", tt.args, tt.expectedCode, code, stderr.String())
+		}
+
+		if !strings.Contains(stdout.String(), tt.expectedStdout) {
+			t.Errorf("wrong stdout for %q, expected it to contain %q, got=%q", tt.args, tt.expectedStdout, stdout.String())
+		}
+
+		if !strings.Contains(stderr.String(), tt.expectedStderr) {
+			t.Errorf("wrong stderr for %q, expected it to contain %q, got=%q", tt.args, tt.expectedStderr, stderr.String())
+		}
+
+		if "" == tt.expectedStderr && "" != stderr.String() {
+			t.Errorf("unexpected stderr for %q, got=%q", tt.args, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Instructions : bytecode, every instruction is an opcode followed by its operands in big endian
+type Instructions []byte
+
+// Opcode :
+type Opcode byte
+
+const (
+	// Pushes the constant at the given index of the constants pool
+	OpConstant Opcode = iota
+	// Discards the top of the stack
+	OpPop
+
+	// Binary operators, they pop the right and the left operands and push the result
+	OpAdd
+	OpSub
+	OpMul
+	OpDivide
+	OpDiv
+	OpMod
+	OpAnd
+	OpOr
+	OpEqual
+	OpNotEqual
+	OpLessThan
+	OpLessThanEqual
+	OpGreaterThan
+	OpGreaterThanEqual
+
+	// Unary operators
+	OpMinus
+	OpNot
+
+	// Jumps to an absolute offset of the current instructions
+	OpJump
+	// Pops a condition, which must be a boolean, and jumps when it is false
+	OpJumpNotTrue
+	// Fails unless the top of the stack is an integer, used on the for loop bounds
+	OpBound
+
+	// Variables, storing converts the value to the type of the one already held
+	OpGetGlobal
+	OpSetGlobal
+	OpGetLocal
+	OpSetLocal
+
+	// Calls the procedure below the given number of arguments
+	OpCall
+	// Leaves the current procedure, pushing null as the value of the call
+	OpReturn
+
+	// Pops a template value and pushes the next input value parsed with its type
+	OpRead
+	// Discards what is left of the input line
+	OpReadln
+	// Pops a value and writes it
+	OpWrite
+	// Writes a line break
+	OpWriteln
+)
+
+// Definition : name and operand widths, in bytes, of an opcode
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant:         {"OpConstant", []int{2}},
+	OpPop:              {"OpPop", []int{}},
+	OpAdd:              {"OpAdd", []int{}},
+	OpSub:              {"OpSub", []int{}},
+	OpMul:              {"OpMul", []int{}},
+	OpDivide:           {"OpDivide", []int{}},
+	OpDiv:              {"OpDiv", []int{}},
+	OpMod:              {"OpMod", []int{}},
+	OpAnd:              {"OpAnd", []int{}},
+	OpOr:               {"OpOr", []int{}},
+	OpEqual:            {"OpEqual", []int{}},
+	OpNotEqual:         {"OpNotEqual", []int{}},
+	OpLessThan:         {"OpLessThan", []int{}},
+	OpLessThanEqual:    {"OpLessThanEqual", []int{}},
+	OpGreaterThan:      {"OpGreaterThan", []int{}},
+	OpGreaterThanEqual: {"OpGreaterThanEqual", []int{}},
+	OpMinus:            {"OpMinus", []int{}},
+	OpNot:              {"OpNot", []int{}},
+	OpJump:             {"OpJump", []int{2}},
+	OpJumpNotTrue:      {"OpJumpNotTrue", []int{2}},
+	OpBound:            {"OpBound", []int{}},
+	OpGetGlobal:        {"OpGetGlobal", []int{2}},
+	OpSetGlobal:        {"OpSetGlobal", []int{2}},
+	OpGetLocal:         {"OpGetLocal", []int{1}},
+	OpSetLocal:         {"OpSetLocal", []int{1}},
+	OpCall:             {"OpCall", []int{1}},
+	OpReturn:           {"OpReturn", []int{}},
+	OpRead:             {"OpRead", []int{}},
+	OpReadln:           {"OpReadln", []int{}},
+	OpWrite:            {"OpWrite", []int{}},
+	OpWriteln:          {"OpWriteln", []int{}},
+}
+
+// Lookup :
+func Lookup(op byte) (*Definition, error) {
+	definition, ok := definitions[Opcode(op)]
+
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+
+	return definition, nil
+}
+
+// Make : encodes a single instruction, an unknown opcode gives an empty one
+func Make(op Opcode, operands ...int) []byte {
+	definition, ok := definitions[op]
+
+	if !ok {
+		return []byte{}
+	}
+
+	length := 1
+
+	for _, width := range definition.OperandWidths {
+		length += width
+	}
+
+	instruction := make([]byte, length)
+	instruction[0] = byte(op)
+
+	offset := 1
+
+	for i, operand := range operands {
+		width := definition.OperandWidths[i]
+
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(operand))
+		case 1:
+			instruction[offset] = byte(operand)
+		}
+
+		offset += width
+	}
+
+	return instruction
+}
+
+// ReadOperands : decodes the operands of an instruction, returning them and how many bytes they took
+func ReadOperands(definition *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(definition.OperandWidths))
+	offset := 0
+
+	for i, width := range definition.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+
+		offset += width
+	}
+
+	return operands, offset
+}
+
+// ReadUint16 :
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+// ReadUint8 :
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
+
+// String : disassembly, one instruction per line prefixed by its offset
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+
+	for i < len(ins) {
+		definition, err := Lookup(ins[i])
+
+		if nil != err {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+
+			return out.String()
+		}
+
+		operands, read := ReadOperands(definition, ins[i+1:])
+
+		fmt.Fprintf(&out, "%04d %s\n", i, ins.formatInstruction(definition, operands))
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+// formatInstruction :
+func (ins Instructions) formatInstruction(definition *Definition, operands []int) string {
+	if len(operands) != len(definition.OperandWidths) {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d", len(operands), len(definition.OperandWidths))
+	}
+
+	switch len(operands) {
+	case 0:
+		return definition.Name
+	case 1:
+		return fmt.Sprintf("%s %d", definition.Name, operands[0])
+	}
+
+	return fmt.Sprintf("ERROR: unhandled operand count for %s", definition.Name)
+}
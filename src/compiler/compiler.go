@@ -0,0 +1,662 @@
+package compiler
+
+import (
+	"fmt"
+
+	"../ast"
+	"../code"
+	"../object"
+	"../token"
+)
+
+// MAX_LOCALS : locals are addressed by a single byte operand
+const MAX_LOCALS = 256
+
+// MAX_GLOBALS : globals are addressed by a two byte operand
+const MAX_GLOBALS = 65536
+
+// operandErrors : what an operand too large for its opcode means in the source
+var operandErrors = map[code.Opcode]string{
+	code.OpConstant:    "too many constants",
+	code.OpJump:        "code too large to jump over",
+	code.OpJumpNotTrue: "code too large to jump over",
+	code.OpCall:        "too many arguments",
+}
+
+var infixOpcodes = map[token.TokenType]code.Opcode{
+	token.PLUS:               code.OpAdd,
+	token.MINUS:              code.OpSub,
+	token.ASTERISK:           code.OpMul,
+	token.SLASH:              code.OpDivide,
+	token.DIV:                code.OpDiv,
+	token.MOD:                code.OpMod,
+	token.AND:                code.OpAnd,
+	token.OR:                 code.OpOr,
+	token.EQUAL:              code.OpEqual,
+	token.DIFFERENT:          code.OpNotEqual,
+	token.LESS_THAN:          code.OpLessThan,
+	token.LESS_THAN_EQUAL:    code.OpLessThanEqual,
+	token.GREATER_THAN:       code.OpGreaterThan,
+	token.GREATER_THAN_EQUAL: code.OpGreaterThanEqual,
+}
+
+var prefixOpcodes = map[token.TokenType]code.Opcode{
+	token.MINUS: code.OpMinus,
+	token.NOT:   code.OpNot,
+}
+
+// Bytecode : instructions of the main program and the constants they refer to, procedures included
+type Bytecode struct {
+	Instructions code.Instructions
+	Positions    map[int]token.Position
+	Constants    []object.Object
+}
+
+// CompilationScope : instructions being emitted for the main program or for a procedure
+type CompilationScope struct {
+	instructions code.Instructions
+	positions    map[int]token.Position
+}
+
+// constantKey : literals of the same type and value share a slot of the constants pool
+type constantKey struct {
+	t     object.ObjectType
+	value interface{}
+}
+
+// Compiler : lowers the AST into bytecode for the virtual machine, keeping the runtime semantics of the evaluator
+type Compiler struct {
+	constants   []object.Object
+	literals    map[constantKey]int
+	symbolTable *SymbolTable
+	scopes      []CompilationScope
+	scopeIndex  int
+	// Counter for the names of the hidden variables of the for loops
+	hidden int
+	// Node being compiled, and the first operand found that does not fit in its instruction
+	position token.Position
+	err      error
+}
+
+// Compile : fails as soon as an instruction cannot encode its operand, instead of emitting a wrapped one
+func (c *Compiler) Compile(node ast.Node) error {
+	outer := c.position
+	c.position = node.Pos()
+
+	err := c.compileNode(node)
+
+	c.position = outer
+
+	if nil != err {
+		return err
+	}
+
+	return c.err
+}
+
+// compileNode :
+func (c *Compiler) compileNode(node ast.Node) error {
+	switch node := node.(type) {
+	case *ast.Program:
+		return c.compileStatements(node.Statements)
+	case *ast.ExpressionStatement:
+		if err := c.Compile(node.Expression); nil != err {
+			return err
+		}
+
+		if isValue(node.Expression) {
+			c.emit(code.OpPop)
+		}
+	case *ast.BlockStatement:
+		return c.compileStatements(node.Statements)
+	case *ast.VarStatement:
+		return c.compileDeclaration(node.Token, node.Name, node.Type, node.Value, false)
+	case *ast.ConstStatement:
+		return c.compileDeclaration(node.Token, node.Name, node.Type, node.Value, true)
+	case *ast.AssignStatement:
+		return c.compileAssignStatement(node)
+	case *ast.ReadStatement:
+		return c.compileReadStatement(node)
+	case *ast.WriteStatement:
+		return c.compileWriteStatement(node)
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(node.Value)
+
+		if !ok {
+			return fmt.Errorf("%s: identifier not found: %s", node.Token.Position, node.Value)
+		}
+
+		c.loadSymbol(symbol)
+	case *ast.IntegerLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: node.Value}))
+	case *ast.RealLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Real{Value: node.Value}))
+	case *ast.StringLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Value}))
+	case *ast.PrefixExpression:
+		return c.compilePrefixExpression(node)
+	case *ast.InfixExpression:
+		return c.compileInfixExpression(node)
+	case *ast.ConditionalExpression:
+		return c.compileConditionalExpression(node)
+	case *ast.WhileLiteral:
+		return c.compileWhileLiteral(node)
+	case *ast.ForLiteral:
+		return c.compileForLiteral(node)
+	case *ast.ProcedureLiteral:
+		return c.compileProcedureLiteral(node)
+	case *ast.CallExpression:
+		return c.compileCallExpression(node)
+	case *ast.ProgramLiteral:
+		return c.compileProgramLiteral(node)
+	}
+
+	return nil
+}
+
+// isValue : expressions that leave a value on the stack, the others are statements in disguise
+func isValue(node ast.Expression) bool {
+	switch node.(type) {
+	case *ast.Identifier, *ast.IntegerLiteral, *ast.RealLiteral, *ast.StringLiteral, *ast.PrefixExpression, *ast.InfixExpression, *ast.CallExpression:
+		return true
+	}
+
+	return false
+}
+
+// compileStatements :
+func (c *Compiler) compileStatements(statements []ast.Statement) error {
+	for _, statement := range statements {
+		if err := c.Compile(statement); nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compileDeclaration : the zero of the declared type is stored first so the value gets converted to that type
+func (c *Compiler) compileDeclaration(tok token.Token, name *ast.Identifier, t token.Token, value ast.Expression, constant bool) error {
+	if nil != value {
+		if err := c.Compile(value); nil != err {
+			return err
+		}
+	}
+
+	symbol, err := c.define(name.Token, name.Value, constant)
+
+	if nil != err {
+		return err
+	}
+
+	c.emit(code.OpConstant, c.addConstant(object.Zero(t.Type)))
+	c.storeSymbol(tok, symbol)
+
+	if nil != value {
+		c.storeSymbol(tok, symbol)
+	}
+
+	return nil
+}
+
+// assignable : symbol of a name that can be stored into
+func (c *Compiler) assignable(identifier *ast.Identifier) (Symbol, error) {
+	symbol, ok := c.symbolTable.Resolve(identifier.Value)
+
+	if !ok {
+		return symbol, fmt.Errorf("%s: identifier not found: %s", identifier.Token.Position, identifier.Value)
+	}
+
+	if symbol.Constant {
+		return symbol, fmt.Errorf("%s: cannot assign to constant %s", identifier.Token.Position, identifier.Value)
+	}
+
+	return symbol, nil
+}
+
+// compileAssignStatement :
+func (c *Compiler) compileAssignStatement(node *ast.AssignStatement) error {
+	if err := c.Compile(node.Value); nil != err {
+		return err
+	}
+
+	symbol, err := c.assignable(node.Name)
+
+	if nil != err {
+		return err
+	}
+
+	c.storeSymbol(node.Token, symbol)
+
+	return nil
+}
+
+// compileReadStatement : the current value of each variable is the template telling how to parse its input
+func (c *Compiler) compileReadStatement(node *ast.ReadStatement) error {
+	for _, argument := range node.Arguments {
+		symbol, err := c.assignable(argument)
+
+		if nil != err {
+			return err
+		}
+
+		c.loadSymbol(symbol)
+		c.emitAt(node.Token, code.OpRead)
+		c.storeSymbol(node.Token, symbol)
+	}
+
+	if token.READLN == node.Token.Type {
+		c.emit(code.OpReadln)
+	}
+
+	return nil
+}
+
+// compileWriteStatement :
+func (c *Compiler) compileWriteStatement(node *ast.WriteStatement) error {
+	for _, argument := range node.Arguments {
+		if err := c.Compile(argument); nil != err {
+			return err
+		}
+
+		c.emit(code.OpWrite)
+	}
+
+	if token.WRITELN == node.Token.Type {
+		c.emit(code.OpWriteln)
+	}
+
+	return nil
+}
+
+// compilePrefixExpression :
+func (c *Compiler) compilePrefixExpression(node *ast.PrefixExpression) error {
+	op, ok := prefixOpcodes[node.Token.Type]
+
+	if !ok {
+		return fmt.Errorf("%s: unknown operator %s", node.Token.Position, node.Operator)
+	}
+
+	if err := c.Compile(node.Right); nil != err {
+		return err
+	}
+
+	c.emitAt(node.Token, op)
+
+	return nil
+}
+
+// compileInfixExpression : both operands are always evaluated, as in the evaluator
+func (c *Compiler) compileInfixExpression(node *ast.InfixExpression) error {
+	op, ok := infixOpcodes[node.Token.Type]
+
+	if !ok {
+		return fmt.Errorf("%s: unknown operator %s", node.Token.Position, node.Operator)
+	}
+
+	if err := c.Compile(node.Left); nil != err {
+		return err
+	}
+
+	if err := c.Compile(node.Right); nil != err {
+		return err
+	}
+
+	c.emitAt(node.Token, op)
+
+	return nil
+}
+
+// compileConditionalExpression :
+func (c *Compiler) compileConditionalExpression(node *ast.ConditionalExpression) error {
+	if err := c.Compile(node.Condition); nil != err {
+		return err
+	}
+
+	jumpNotTrue := c.emitAt(node.Token, code.OpJumpNotTrue, 9999)
+
+	if err := c.Compile(node.Consequence); nil != err {
+		return err
+	}
+
+	if nil == node.Alternative {
+		c.changeOperand(jumpNotTrue, len(c.currentInstructions()))
+
+		return nil
+	}
+
+	jump := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpNotTrue, len(c.currentInstructions()))
+
+	if err := c.Compile(node.Alternative); nil != err {
+		return err
+	}
+
+	c.changeOperand(jump, len(c.currentInstructions()))
+
+	return nil
+}
+
+// compileWhileLiteral :
+func (c *Compiler) compileWhileLiteral(node *ast.WhileLiteral) error {
+	start := len(c.currentInstructions())
+
+	if err := c.Compile(node.Condition); nil != err {
+		return err
+	}
+
+	jumpNotTrue := c.emitAt(node.Token, code.OpJumpNotTrue, 9999)
+
+	if err := c.Compile(node.Body); nil != err {
+		return err
+	}
+
+	c.emit(code.OpJump, start)
+	c.changeOperand(jumpNotTrue, len(c.currentInstructions()))
+
+	return nil
+}
+
+// compileForLiteral : the bounds are kept in hidden variables, evaluated once, and the loop variable is only assigned
+// when an iteration runs, as in the evaluator
+func (c *Compiler) compileForLiteral(node *ast.ForLiteral) error {
+	variable, err := c.assignable(node.Variable)
+
+	if nil != err {
+		return err
+	}
+
+	c.hidden++
+	counter, _ := c.define(node.Token, fmt.Sprintf("for#%d", c.hidden), false)
+	limit, err := c.define(node.Token, fmt.Sprintf("for#%d#limit", c.hidden), false)
+
+	if nil != err {
+		return err
+	}
+
+	for _, bound := range []struct {
+		expression ast.Expression
+		symbol     Symbol
+	}{{node.From, counter}, {node.To, limit}} {
+		if err := c.Compile(bound.expression); nil != err {
+			return err
+		}
+
+		c.emitAt(node.Token, code.OpBound)
+		c.storeSymbol(node.Token, bound.symbol)
+	}
+
+	start := len(c.currentInstructions())
+
+	c.loadSymbol(counter)
+	c.loadSymbol(limit)
+	c.emit(code.OpLessThanEqual)
+	jumpNotTrue := c.emit(code.OpJumpNotTrue, 9999)
+
+	c.loadSymbol(counter)
+	c.storeSymbol(node.Variable.Token, variable)
+
+	if err := c.Compile(node.Body); nil != err {
+		return err
+	}
+
+	// the last value ends the loop before the counter goes past it, which would overflow at the largest integer
+	c.loadSymbol(counter)
+	c.loadSymbol(limit)
+	c.emit(code.OpNotEqual)
+	last := c.emit(code.OpJumpNotTrue, 9999)
+
+	c.loadSymbol(counter)
+	c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: 1}))
+	c.emitAt(node.Token, code.OpAdd)
+	c.storeSymbol(node.Token, counter)
+	c.emit(code.OpJump, start)
+
+	c.changeOperand(jumpNotTrue, len(c.currentInstructions()))
+	c.changeOperand(last, len(c.currentInstructions()))
+
+	return nil
+}
+
+// compileProcedureLiteral : the procedure is defined before its body is compiled so it may call itself
+func (c *Compiler) compileProcedureLiteral(node *ast.ProcedureLiteral) error {
+	if 0 != c.scopeIndex {
+		return fmt.Errorf("%s: nested procedures are not supported", node.Token.Position)
+	}
+
+	symbol, err := c.define(node.Token, node.Name, true)
+
+	if nil != err {
+		return err
+	}
+
+	c.enterScope()
+
+	parameters := []object.Object{}
+
+	for _, parameter := range node.Parameters {
+		if _, err := c.define(parameter.Token, parameter.Value, false); nil != err {
+			return err
+		}
+
+		parameters = append(parameters, object.Zero(parameter.Type.Type))
+	}
+
+	if err := c.compileStatements(node.Declarations); nil != err {
+		return err
+	}
+
+	if err := c.Compile(node.Body); nil != err {
+		return err
+	}
+
+	c.emit(code.OpReturn)
+
+	numLocals := c.symbolTable.numDefinitions
+	instructions, positions := c.leaveScope()
+
+	procedure := &object.CompiledProcedure{
+		Name:         node.Name,
+		Instructions: instructions,
+		Positions:    positions,
+		NumLocals:    numLocals,
+		Parameters:   parameters,
+	}
+
+	c.emit(code.OpConstant, c.addConstant(procedure))
+	c.storeSymbol(node.Token, symbol)
+
+	return nil
+}
+
+// compileCallExpression :
+func (c *Compiler) compileCallExpression(node *ast.CallExpression) error {
+	if err := c.Compile(node.Procedure); nil != err {
+		return err
+	}
+
+	for _, argument := range node.Arguments {
+		if err := c.Compile(argument); nil != err {
+			return err
+		}
+	}
+
+	c.emitAt(node.Token, code.OpCall, len(node.Arguments))
+
+	return nil
+}
+
+// compileProgramLiteral :
+func (c *Compiler) compileProgramLiteral(node *ast.ProgramLiteral) error {
+	if err := c.compileStatements(node.Declarations); nil != err {
+		return err
+	}
+
+	for _, procedure := range node.Procedures {
+		if err := c.compileProcedureLiteral(procedure); nil != err {
+			return err
+		}
+	}
+
+	return c.Compile(node.Body)
+}
+
+// define : fails when the program or a procedure runs out of variable slots
+func (c *Compiler) define(tok token.Token, name string, constant bool) (Symbol, error) {
+	symbol := c.symbolTable.Define(name, constant)
+
+	if LOCAL_SCOPE == symbol.Scope && MAX_LOCALS <= symbol.Index {
+		return symbol, fmt.Errorf("%s: too many local variables, the limit is %d", tok.Position, MAX_LOCALS)
+	}
+
+	if GLOBAL_SCOPE == symbol.Scope && MAX_GLOBALS <= symbol.Index {
+		return symbol, fmt.Errorf("%s: too many global variables, the limit is %d", tok.Position, MAX_GLOBALS)
+	}
+
+	return symbol, nil
+}
+
+// loadSymbol :
+func (c *Compiler) loadSymbol(symbol Symbol) {
+	if GLOBAL_SCOPE == symbol.Scope {
+		c.emit(code.OpGetGlobal, symbol.Index)
+	} else {
+		c.emit(code.OpGetLocal, symbol.Index)
+	}
+}
+
+// storeSymbol : stores can fail converting the value, so their position is kept
+func (c *Compiler) storeSymbol(tok token.Token, symbol Symbol) {
+	if GLOBAL_SCOPE == symbol.Scope {
+		c.emitAt(tok, code.OpSetGlobal, symbol.Index)
+	} else {
+		c.emitAt(tok, code.OpSetLocal, symbol.Index)
+	}
+}
+
+// addConstant : literals already in the pool are reused
+func (c *Compiler) addConstant(obj object.Object) int {
+	var key *constantKey
+
+	switch obj := obj.(type) {
+	case *object.Integer:
+		key = &constantKey{obj.Type(), obj.Value}
+	case *object.Real:
+		key = &constantKey{obj.Type(), obj.Value}
+	case *object.String:
+		key = &constantKey{obj.Type(), obj.Value}
+	}
+
+	if nil != key {
+		if index, ok := c.literals[*key]; ok {
+			return index
+		}
+
+		c.literals[*key] = len(c.constants)
+	}
+
+	c.constants = append(c.constants, obj)
+
+	return len(c.constants) - 1
+}
+
+// currentInstructions :
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.scopes[c.scopeIndex].instructions
+}
+
+// fits : records an error for the first operand too large to be encoded, which Compile then returns
+func (c *Compiler) fits(op code.Opcode, operands []int) {
+	definition, err := code.Lookup(byte(op))
+
+	if nil != err || nil != c.err {
+		return
+	}
+
+	for i, width := range definition.OperandWidths {
+		limit := 1<<uint(8*width) - 1
+
+		if operands[i] > limit {
+			c.err = fmt.Errorf("%s: %s, the limit is %d", c.position, operandErrors[op], limit)
+
+			return
+		}
+	}
+}
+
+// emit : appends the instruction to the current scope, returning its offset; runtime errors of the instruction are
+// reported at the node being compiled
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	position := len(c.currentInstructions())
+
+	c.fits(op, operands)
+
+	c.scopes[c.scopeIndex].instructions = append(c.currentInstructions(), code.Make(op, operands...)...)
+	c.scopes[c.scopeIndex].positions[position] = c.position
+
+	return position
+}
+
+// emitAt : emits an instruction that can fail at runtime, remembering where it came from
+func (c *Compiler) emitAt(tok token.Token, op code.Opcode, operands ...int) int {
+	position := c.emit(op, operands...)
+
+	c.scopes[c.scopeIndex].positions[position] = tok.Position
+
+	return position
+}
+
+// changeOperand : patches the operand of an already emitted jump
+func (c *Compiler) changeOperand(position int, operand int) {
+	op := code.Opcode(c.currentInstructions()[position])
+	instruction := code.Make(op, operand)
+
+	c.fits(op, []int{operand})
+
+	copy(c.scopes[c.scopeIndex].instructions[position:], instruction)
+}
+
+// enterScope :
+func (c *Compiler) enterScope() {
+	c.scopes = append(c.scopes, CompilationScope{
+		instructions: code.Instructions{},
+		positions:    make(map[int]token.Position),
+	})
+	c.scopeIndex++
+	c.symbolTable = InitializeEnclosedSymbolTable(c.symbolTable)
+}
+
+// leaveScope :
+func (c *Compiler) leaveScope() (code.Instructions, map[int]token.Position) {
+	scope := c.scopes[c.scopeIndex]
+
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+	c.symbolTable = c.symbolTable.Outer
+
+	return scope.instructions, scope.positions
+}
+
+// Bytecode :
+func (c *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: c.currentInstructions(),
+		Positions:    c.scopes[c.scopeIndex].positions,
+		Constants:    c.constants,
+	}
+}
+
+// InitializeCompiler :
+func InitializeCompiler() *Compiler {
+	return &Compiler{
+		constants:   []object.Object{},
+		literals:    make(map[constantKey]int),
+		symbolTable: InitializeSymbolTable(),
+		scopes: []CompilationScope{
+			{
+				instructions: code.Instructions{},
+				positions:    make(map[int]token.Position),
+			},
+		},
+	}
+}
@@ -0,0 +1,397 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"../code"
+	"../lexer"
+	"../object"
+	"../parser"
+)
+
+type compilerTestCase struct {
+	input                string
+	expectedConstants    []interface{}
+	expectedInstructions []code.Instructions
+}
+
+// testCompile :
+func testCompile(t *testing.T, input string) (*Bytecode, error) {
+	l := lexer.InitializeLexer(input)
+	p := parser.InitializeParser(l)
+	program := p.ParseProgram()
+
+	if 0 != len(p.Errors()) {
+		t.Fatalf("parser errors for %q: %q", input, p.Errors())
+	}
+
+	c := InitializeCompiler()
+	err := c.Compile(program)
+
+	return c.Bytecode(), err
+}
+
+// concatInstructions :
+func concatInstructions(s []code.Instructions) code.Instructions {
+	out := code.Instructions{}
+
+	for _, ins := range s {
+		out = append(out, ins...)
+	}
+
+	return out
+}
+
+// testInstructions :
+func testInstructions(t *testing.T, input string, expected []code.Instructions, actual code.Instructions) {
+	concatted := concatInstructions(expected)
+
+	if actual.String() != concatted.String() {
+		t.Errorf("wrong instructions for %q\nexpected=\n%s\ngot=\n%s", input, concatted, actual)
+	}
+}
+
+// testConstants :
+func testConstants(t *testing.T, input string, expected []interface{}, actual []object.Object) {
+	if len(expected) != len(actual) {
+		t.Fatalf("wrong number of constants for %q, expected=%d, got=%d", input, len(expected), len(actual))
+	}
+
+	for i, constant := range expected {
+		switch constant := constant.(type) {
+		case int:
+			integer, ok := actual[i].(*object.Integer)
+
+			if !ok || integer.Value != int64(constant) {
+				t.Errorf("constant %d wrong for %q, expected=%d, got=%s", i, input, constant, actual[i].Inspect())
+			}
+		case float64:
+			real, ok := actual[i].(*object.Real)
+
+			if !ok || real.Value != constant {
+				t.Errorf("constant %d wrong for %q, expected=%g, got=%s", i, input, constant, actual[i].Inspect())
+			}
+		case []code.Instructions:
+			procedure, ok := actual[i].(*object.CompiledProcedure)
+
+			if !ok {
+				t.Fatalf("constant %d is not a CompiledProcedure, got=%T", i, actual[i])
+			}
+
+			testInstructions(t, input, constant, procedure.Instructions)
+		}
+	}
+}
+
+// runCompilerTests :
+func runCompilerTests(t *testing.T, tests []compilerTestCase) {
+	for _, tt := range tests {
+		bytecode, err := testCompile(t, tt.input)
+
+		if nil != err {
+			t.Fatalf("compiler error for %q: %s", tt.input, err)
+		}
+
+		testInstructions(t, tt.input, tt.expectedInstructions, bytecode.Instructions)
+		testConstants(t, tt.input, tt.expectedConstants, bytecode.Constants)
+	}
+}
+
+func TestExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "1 + 2.5",
+			expectedConstants: []interface{}{1, 2.5},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "-7 div 2 mod 3",
+			expectedConstants: []interface{}{7, 2, 3},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpMinus),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpDiv),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpMod),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "not (1 <> 2) or (3 >= 4)",
+			expectedConstants: []interface{}{1, 2, 3, 4},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpNotEqual),
+				code.Make(code.OpNot),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpGreaterThanEqual),
+				code.Make(code.OpOr),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestStatements(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "var x: real := 1; x := x / 2;",
+			expectedConstants: []interface{}{1, 0.0, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpDivide),
+				code.Make(code.OpSetGlobal, 0),
+			},
+		},
+		{
+			input:             "var x: integer; readln(x); writeln(x, 1)",
+			expectedConstants: []interface{}{0, 1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpRead),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpReadln),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpWrite),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpWrite),
+				code.Make(code.OpWriteln),
+			},
+		},
+		{
+			input:             "if 1 < 2 then write(3) end else write(4) end",
+			expectedConstants: []interface{}{1, 2, 3, 4},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpConstant, 0),
+				// 0003
+				code.Make(code.OpConstant, 1),
+				// 0006
+				code.Make(code.OpLessThan),
+				// 0007
+				code.Make(code.OpJumpNotTrue, 17),
+				// 0010
+				code.Make(code.OpConstant, 2),
+				// 0013
+				code.Make(code.OpWrite),
+				// 0014
+				code.Make(code.OpJump, 21),
+				// 0017
+				code.Make(code.OpConstant, 3),
+				// 0020
+				code.Make(code.OpWrite),
+			},
+		},
+		{
+			input:             "var x: integer; while x < 3 do x := x + 1",
+			expectedConstants: []interface{}{0, 3, 1},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpConstant, 0),
+				// 0003
+				code.Make(code.OpSetGlobal, 0),
+				// 0006
+				code.Make(code.OpGetGlobal, 0),
+				// 0009
+				code.Make(code.OpConstant, 1),
+				// 0012
+				code.Make(code.OpLessThan),
+				// 0013
+				code.Make(code.OpJumpNotTrue, 29),
+				// 0016
+				code.Make(code.OpGetGlobal, 0),
+				// 0019
+				code.Make(code.OpConstant, 2),
+				// 0022
+				code.Make(code.OpAdd),
+				// 0023
+				code.Make(code.OpSetGlobal, 0),
+				// 0026
+				code.Make(code.OpJump, 6),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestProcedures(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `program p;
+var g: integer;
+procedure f(a: integer);
+var b: real;
+begin
+	g := a
+end;
+begin
+	f(1)
+end.`,
+			expectedConstants: []interface{}{
+				0,
+				0.0,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpSetLocal, 1),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpSetGlobal, 0),
+					code.Make(code.OpReturn),
+				},
+				1,
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpSetGlobal, 1),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpCall, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestCompilerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x := 1;", "1:1: identifier not found: x"},
+		{"const x: integer := 1; read(x);", "1:29: cannot assign to constant x"},
+		{"procedure f; begin procedure g; begin end end", "1:20: nested procedures are not supported"},
+	}
+
+	for _, tt := range tests {
+		_, err := testCompile(t, tt.input)
+
+		if nil == err {
+			t.Fatalf("expected an error for %q", tt.input)
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error for %q, expected=%q, got=%q", tt.input, tt.expected, err.Error())
+		}
+	}
+}
+
+func TestConstantPool(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "1; 2.5; 1; 2.5; 1.0",
+			expectedConstants: []interface{}{1, 2.5, 1.0},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+// TestOperandLimits : programs too large for the operands of the instructions fail instead of wrapping around
+func TestOperandLimits(t *testing.T) {
+	var constants, globals, jump strings.Builder
+
+	constants.WriteString("var x: integer;\n")
+
+	for i := 0; i < 70000; i++ {
+		fmt.Fprintf(&constants, "x := %d;\n", i)
+		fmt.Fprintf(&globals, "var v%d: integer;\n", i)
+	}
+
+	jump.WriteString("var x: integer;\nif 1 = 1 then\n")
+	jump.WriteString(strings.Repeat("x := 1;\n", 12000))
+	jump.WriteString("end")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{constants.String(), "65538:6: too many constants, the limit is 65535"},
+		{globals.String(), "65537:5: too many global variables, the limit is 65536"},
+		{jump.String(), "2:1: code too large to jump over, the limit is 65535"},
+	}
+
+	for _, tt := range tests {
+		_, err := testCompile(t, tt.input)
+
+		if nil == err {
+			t.Fatalf("expected an error for %.20q", tt.input)
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error for %.20q, expected=%q, got=%q", tt.input, tt.expected, err.Error())
+		}
+	}
+
+	largest := constants.String()[:strings.Index(constants.String(), "x := 65536;")]
+
+	if _, err := testCompile(t, largest); nil != err {
+		t.Errorf("the largest constants pool should compile, got=%s", err)
+	}
+}
+
+func TestSymbolTable(t *testing.T) {
+	global := InitializeSymbolTable()
+	a := global.Define("a", false)
+	local := InitializeEnclosedSymbolTable(global)
+	b := local.Define("b", true)
+	shadow := local.Define("a", false)
+
+	expected := []Symbol{
+		{Name: "a", Scope: GLOBAL_SCOPE, Index: 0},
+		{Name: "b", Scope: LOCAL_SCOPE, Index: 0, Constant: true},
+		{Name: "a", Scope: LOCAL_SCOPE, Index: 1},
+	}
+
+	for i, symbol := range []Symbol{a, b, shadow} {
+		if symbol != expected[i] {
+			t.Errorf("symbol %d wrong, expected=%+v, got=%+v", i, expected[i], symbol)
+		}
+	}
+
+	if resolved, ok := local.Resolve("a"); !ok || resolved != shadow {
+		t.Errorf("local a should shadow the global one, got=%+v", resolved)
+	}
+
+	if resolved, ok := global.Resolve("a"); !ok || resolved != a {
+		t.Errorf("global a wrong, got=%+v", resolved)
+	}
+
+	if _, ok := global.Resolve("b"); ok {
+		t.Errorf("global table should not see local b")
+	}
+}
@@ -0,0 +1,70 @@
+package compiler
+
+// SymbolScope :
+type SymbolScope string
+
+const (
+	GLOBAL_SCOPE SymbolScope = "GLOBAL"
+	LOCAL_SCOPE  SymbolScope = "LOCAL"
+)
+
+// Symbol : storage slot given to a name
+type Symbol struct {
+	Name     string
+	Scope    SymbolScope
+	Index    int
+	Constant bool
+}
+
+// SymbolTable : names of the main program, which are globals, or of a single procedure, which are locals
+type SymbolTable struct {
+	Outer          *SymbolTable
+	store          map[string]Symbol
+	numDefinitions int
+}
+
+// Define : every definition gets a fresh slot, so a redeclaration never converts the value of the previous one
+func (s *SymbolTable) Define(name string, constant bool) Symbol {
+	symbol := Symbol{
+		Name:     name,
+		Index:    s.numDefinitions,
+		Constant: constant,
+	}
+
+	if nil == s.Outer {
+		symbol.Scope = GLOBAL_SCOPE
+	} else {
+		symbol.Scope = LOCAL_SCOPE
+	}
+
+	s.store[name] = symbol
+	s.numDefinitions++
+
+	return symbol
+}
+
+// Resolve : looks the name up in this table and then in the enclosing ones
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	symbol, ok := s.store[name]
+
+	if !ok && nil != s.Outer {
+		return s.Outer.Resolve(name)
+	}
+
+	return symbol, ok
+}
+
+// InitializeSymbolTable :
+func InitializeSymbolTable() *SymbolTable {
+	return &SymbolTable{
+		store: make(map[string]Symbol),
+	}
+}
+
+// InitializeEnclosedSymbolTable :
+func InitializeEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	table := InitializeSymbolTable()
+	table.Outer = outer
+
+	return table
+}
@@ -0,0 +1,211 @@
+package diagnostic
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"../token"
+)
+
+// Severity :
+type Severity int
+
+const (
+	ERROR Severity = iota
+	WARNING
+	NOTE
+)
+
+// Code : identifies the kind of a diagnostic, the first letter tells which phase reported it
+type Code string
+
+const (
+	// Lexical errors
+	INVALID_CHARACTER    Code = "L001"
+	UNTERMINATED_COMMENT Code = "L002"
+	MALFORMED_NUMBER     Code = "L003"
+	IDENTIFIER_TOO_LONG  Code = "L004"
+	NUMBER_OUT_OF_RANGE  Code = "L005"
+	INVALID_UTF8         Code = "L006"
+	UNTERMINATED_STRING  Code = "L007"
+
+	// Syntax errors
+	UNEXPECTED_TOKEN         Code = "P001"
+	NO_PREFIX_PARSE_FUNCTION Code = "P002"
+	INVALID_INTEGER          Code = "P003"
+	INVALID_REAL             Code = "P004"
+	INVALID_READ_TARGET      Code = "P005"
+	MISSING_ARGUMENTS        Code = "P006"
+
+	// Semantic errors and warnings
+	UNDECLARED_IDENTIFIER Code = "S001"
+	REDECLARED_IDENTIFIER Code = "S002"
+	SHADOWED_IDENTIFIER   Code = "S003"
+	NOT_ASSIGNABLE        Code = "S004"
+	NOT_A_PROCEDURE       Code = "S005"
+	MISMATCHED_TYPES      Code = "S006"
+	INVALID_OPERANDS      Code = "S007"
+	WRONG_ARGUMENT_COUNT  Code = "S008"
+	UNCALLED_PROCEDURE    Code = "S009"
+)
+
+// Diagnostic : a single message reported by any of the compiler phases
+type Diagnostic struct {
+	Severity Severity
+	Code     Code
+	// Source span the diagnostic refers to, End is exclusive
+	Start token.Position
+	End   token.Position
+	// Tokens that would have been accepted, if any, and the one found instead
+	Expected []token.TokenType
+	Found    token.Token
+	Message  string
+}
+
+// List : sortable and filterable set of diagnostics
+type List []Diagnostic
+
+// String :
+func (s Severity) String() string {
+	switch s {
+	case ERROR:
+		return "error"
+	case WARNING:
+		return "warning"
+	case NOTE:
+		return "note"
+	}
+
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// String : "line:column: message", the same format used by the old string based errors
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Start, d.Message)
+}
+
+// Format : includes the severity and the code alongside the message
+func (d Diagnostic) Format() string {
+	return fmt.Sprintf("%s: %s[%s]: %s", d.Start, d.Severity, d.Code, d.Message)
+}
+
+// JoinTokenTypes : token types joined in a human friendly way, as in "INTEGER_KEYWORD or REAL_KEYWORD"
+func JoinTokenTypes(types []token.TokenType) string {
+	names := []string{}
+
+	for _, t := range types {
+		names = append(names, t.String())
+	}
+
+	return strings.Join(names, " or ")
+}
+
+// Len :
+func (l List) Len() int {
+	return len(l)
+}
+
+// Less : orders by source position, keeping the report order for the same position
+func (l List) Less(i, j int) bool {
+	return l[i].Start.Offset < l[j].Start.Offset
+}
+
+// Swap :
+func (l List) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+// Sort :
+func (l List) Sort() {
+	sort.Stable(l)
+}
+
+// Filter : diagnostics for which keep returns true
+func (l List) Filter(keep func(Diagnostic) bool) List {
+	filtered := List{}
+
+	for _, d := range l {
+		if keep(d) {
+			filtered = append(filtered, d)
+		}
+	}
+
+	return filtered
+}
+
+// Errors : only the diagnostics with the ERROR severity
+func (l List) Errors() List {
+	return l.Filter(func(d Diagnostic) bool {
+		return ERROR == d.Severity
+	})
+}
+
+// HasErrors :
+func (l List) HasErrors() bool {
+	for _, d := range l {
+		if ERROR == d.Severity {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Strings : compatibility view for the code that still handles errors as plain strings
+func (l List) Strings() []string {
+	messages := []string{}
+
+	for _, d := range l {
+		messages = append(messages, d.String())
+	}
+
+	return messages
+}
+
+// Underline : the source line where the diagnostic starts followed by a line marking its span, as in
+//
+//	a := b + 'c';
+//	     ^~~~~~~
+//
+// spans over several lines are marked up to the end of the first one; empty when the position is not in the source
+func Underline(source string, d Diagnostic) string {
+	lines := strings.Split(source, "\n")
+
+	if d.Start.Line < 1 || d.Start.Line > len(lines) {
+		return ""
+	}
+
+	line := []rune(strings.TrimSuffix(lines[d.Start.Line-1], "\r"))
+	start := d.Start.Column - 1
+
+	if start < 0 || start > len(line) {
+		return ""
+	}
+
+	end := len(line)
+
+	if d.End.Line == d.Start.Line && d.End.Column-1 < end {
+		end = d.End.Column - 1
+	}
+
+	var marker bytes.Buffer
+
+	// tabs are kept so the marker lines up however wide they are shown
+	for _, r := range line[:start] {
+		if '\t' == r {
+			marker.WriteRune('\t')
+		} else {
+			marker.WriteRune(' ')
+		}
+	}
+
+	marker.WriteRune('^')
+
+	for i := start + 1; i < end; i++ {
+		marker.WriteRune('~')
+	}
+
+	return string(line) + "\n" + marker.String()
+}
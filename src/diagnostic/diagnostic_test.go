@@ -0,0 +1,93 @@
+package diagnostic
+
+import (
+	"testing"
+
+	"../token"
+)
+
+// TestList :
+func TestList(t *testing.T) {
+	diagnostics := List{
+		{
+			Severity: WARNING,
+			Code:     UNEXPECTED_TOKEN,
+			Start:    token.Position{Line: 2, Column: 1, Offset: 10},
+			Message:  "second",
+		},
+		{
+			Severity: ERROR,
+			Code:     INVALID_CHARACTER,
+			Start:    token.Position{Line: 1, Column: 3, Offset: 2},
+			Message:  "first",
+		},
+		{
+			Severity: ERROR,
+			Code:     NO_PREFIX_PARSE_FUNCTION,
+			Start:    token.Position{Line: 2, Column: 1, Offset: 10},
+			Message:  "third",
+		},
+	}
+
+	diagnostics.Sort()
+
+	expected := []string{
+		"1:3: first",
+		"2:1: second",
+		"2:1: third",
+	}
+
+	for i, message := range diagnostics.Strings() {
+		if message != expected[i] {
+			t.Errorf("diagnostics[%d] wrong, expected=%q, got=%q", i, expected[i], message)
+		}
+	}
+
+	errors := diagnostics.Errors()
+
+	if 2 != len(errors) || !errors.HasErrors() {
+		t.Fatalf("errors filter wrong, got=%q", errors.Strings())
+	}
+
+	warnings := diagnostics.Filter(func(d Diagnostic) bool {
+		return WARNING == d.Severity
+	})
+
+	if 1 != len(warnings) || warnings.HasErrors() {
+		t.Fatalf("warnings filter wrong, got=%q", warnings.Strings())
+	}
+
+	if "2:1: warning[P001]: second" != warnings[0].Format() {
+		t.Errorf("Format() wrong, got=%q", warnings[0].Format())
+	}
+}
+
+// TestUnderline :
+func TestUnderline(t *testing.T) {
+	source := "var a: integer;\n\ta := 'ação' + 1;\r\nwhile a\n< 2 do"
+
+	span := func(startLine int, startColumn int, endLine int, endColumn int) Diagnostic {
+		return Diagnostic{
+			Start: token.Position{Line: startLine, Column: startColumn},
+			End:   token.Position{Line: endLine, Column: endColumn},
+		}
+	}
+
+	tests := []struct {
+		diagnostic Diagnostic
+		expected   string
+	}{
+		{span(1, 5, 1, 6), "var a: integer;\n    ^"},
+		{span(2, 7, 2, 17), "\ta := 'ação' + 1;\n\t     ^~~~~~~~~~"},
+		{span(3, 1, 4, 4), "while a\n^~~~~~~"},
+		{span(1, 16, 1, 16), "var a: integer;\n               ^"},
+		{span(5, 1, 5, 2), ""},
+		{span(1, 20, 1, 21), ""},
+	}
+
+	for _, tt := range tests {
+		if underline := Underline(source, tt.diagnostic); underline != tt.expected {
+			t.Errorf("wrong underline for %s, expected=%q, got=%q", tt.diagnostic.Start, tt.expected, underline)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package dump
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"../diagnostic"
+	"../lexer"
+	"../token"
+)
+
+// Format : how each token is printed
+type Format string
+
+const (
+	// "<string> - <token>", as in the expected outputs of the lexical analysis assignment
+	PAIR_FORMAT Format = "pair"
+	// Aligned columns with the position, type and literal of the token
+	TABLE_FORMAT Format = "table"
+	// One JSON object per token
+	JSON_FORMAT Format = "json"
+)
+
+// FORMATS : every supported format, in the order they are documented
+var FORMATS = []Format{PAIR_FORMAT, TABLE_FORMAT, JSON_FORMAT}
+
+// symbols : names the assignment gives to the symbol tokens
+var symbols = map[token.TokenType]string{
+	token.IDENTIFIER:         "id",
+	token.INTEGER:            "num_int",
+	token.REAL:               "num_real",
+	token.STRING:             "cadeia",
+	token.ASSIGN:             "simb_atribuicao",
+	token.PLUS:               "simb_mais",
+	token.MINUS:              "simb_menos",
+	token.SLASH:              "simb_divisao",
+	token.ASTERISK:           "simb_multiplicacao",
+	token.LESS_THAN:          "simb_menor",
+	token.GREATER_THAN:       "simb_maior",
+	token.LESS_THAN_EQUAL:    "simb_menor_igual",
+	token.GREATER_THAN_EQUAL: "simb_maior_igual",
+	token.EQUAL:              "simb_igual",
+	token.DIFFERENT:          "simb_diferente",
+	token.COMMA:              "simb_virgula",
+	token.COLON:              "simb_dois_pontos",
+	token.SEMICOLON:          "simb_ponto_virgula",
+	token.DOT:                "simb_ponto",
+	token.LEFT_PARENTHESIS:   "simb_abre_parentese",
+	token.RIGHT_PARENTHESIS:  "simb_fecha_parentese",
+	token.ILLEGAL:            "erro",
+}
+
+// errors : descriptions the assignment gives to each lexical error
+var errors = map[diagnostic.Code]string{
+	diagnostic.INVALID_CHARACTER:    "simbolo nao pertencente a linguagem",
+	diagnostic.UNTERMINATED_COMMENT: "comentario nao fechado",
+	diagnostic.MALFORMED_NUMBER:     "numero mal formado",
+	diagnostic.IDENTIFIER_TOO_LONG:  "identificador muito grande",
+	diagnostic.NUMBER_OUT_OF_RANGE:  "numero fora do intervalo",
+	diagnostic.INVALID_UTF8:         "codificacao de caractere invalida",
+	diagnostic.UNTERMINATED_STRING:  "cadeia nao fechada",
+}
+
+// Entry : a token together with its name in the assignment and, for the invalid ones, the lexical error
+type Entry struct {
+	Type    token.TokenType `json:"type"`
+	Class   string          `json:"class"`
+	Literal string          `json:"literal"`
+	Line    int             `json:"line"`
+	Column  int             `json:"column"`
+	Offset  int             `json:"offset"`
+	Error   string          `json:"error,omitempty"`
+	// Description of the error in the words of the assignment
+	description string
+}
+
+// Class : name of the token in the assignment, reserved words are named after themselves
+func Class(tok token.Token) string {
+	if class, ok := symbols[tok.Type]; ok {
+		return class
+	}
+
+	return strings.ToLower(tok.Literal)
+}
+
+// EachEntry : visits every token of the source up to, but not including, the end of file as soon as it is read,
+// stopping at the first error visit returns
+func EachEntry(l *lexer.Lexer, visit func(entry Entry) error) error {
+	for {
+		reported := len(l.Diagnostics())
+		tok := l.NextToken()
+
+		// an unterminated comment swallows the rest of the source, its opening brace being the last entry
+		for _, d := range l.Diagnostics()[reported:] {
+			if diagnostic.UNTERMINATED_COMMENT != d.Code {
+				continue
+			}
+
+			err := visit(Entry{
+				Type:        token.COMMENT,
+				Class:       symbols[token.ILLEGAL],
+				Literal:     "{",
+				Line:        d.Start.Line,
+				Column:      d.Start.Column,
+				Offset:      d.Start.Offset,
+				Error:       d.Message,
+				description: describe(d),
+			})
+
+			if nil != err {
+				return err
+			}
+		}
+
+		if token.EOF == tok.Type {
+			return nil
+		}
+
+		entry := Entry{
+			Type:    tok.Type,
+			Class:   Class(tok),
+			Literal: tok.Literal,
+			Line:    tok.Position.Line,
+			Column:  tok.Position.Column,
+			Offset:  tok.Position.Offset,
+		}
+
+		// identifiers that are too long keep their type, but are still errors for the assignment
+		for _, d := range l.Diagnostics()[reported:] {
+			if d.Start == tok.Position {
+				entry.Class = symbols[token.ILLEGAL]
+				entry.Error, entry.description = d.Message, describe(d)
+			}
+		}
+
+		if err := visit(entry); nil != err {
+			return err
+		}
+	}
+}
+
+// describe : description of the diagnostic in the words of the assignment
+func describe(d diagnostic.Diagnostic) string {
+	if description, ok := errors[d.Code]; ok {
+		return description
+	}
+
+	return d.Message
+}
+
+// Dump : writes every token of the source in the given format, each one as soon as it is read so no input is too
+// large to be dumped
+func Dump(l *lexer.Lexer, format Format, out io.Writer) error {
+	var write func(entry Entry) error
+
+	switch format {
+	case PAIR_FORMAT:
+		write = func(entry Entry) error {
+			var err error
+
+			if "" != entry.description {
+				_, err = fmt.Fprintf(out, "%s - %s - %s\n", entry.Literal, entry.Class, entry.description)
+			} else {
+				_, err = fmt.Fprintf(out, "%s - %s\n", entry.Literal, entry.Class)
+			}
+
+			return err
+		}
+	case TABLE_FORMAT:
+		if _, err := fmt.Fprintf(out, "%-10s %-20s %-22s %s\n", "POSITION", "TYPE", "CLASS", "LITERAL"); nil != err {
+			return err
+		}
+
+		write = func(entry Entry) error {
+			position := fmt.Sprintf("%d:%d", entry.Line, entry.Column)
+			line := fmt.Sprintf("%-10s %-20s %-22s %s", position, entry.Type, entry.Class, entry.Literal)
+
+			if "" != entry.Error {
+				line += "  (" + entry.Error + ")"
+			}
+
+			_, err := fmt.Fprintln(out, line)
+
+			return err
+		}
+	case JSON_FORMAT:
+		encoder := json.NewEncoder(out)
+
+		write = func(entry Entry) error {
+			return encoder.Encode(entry)
+		}
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	return EachEntry(l, write)
+}
@@ -0,0 +1,169 @@
+package dump
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"../lexer"
+)
+
+const assignment = `program lalg;
+var a: integer;
+begin
+{entrada}
+readd(a,@,1);
+end.`
+
+func TestPairFormat(t *testing.T) {
+	expected := `program - program
+lalg - id
+; - simb_ponto_virgula
+var - var
+a - id
+: - simb_dois_pontos
+integer - integer
+; - simb_ponto_virgula
+begin - begin
+readd - id
+( - simb_abre_parentese
+a - id
+, - simb_virgula
+@ - erro - simbolo nao pertencente a linguagem
+, - simb_virgula
+1 - num_int
+) - simb_fecha_parentese
+; - simb_ponto_virgula
+end - end
+. - simb_ponto
+`
+
+	var out bytes.Buffer
+
+	if err := Dump(lexer.InitializeLexer(assignment), PAIR_FORMAT, &out); nil != err {
+		t.Fatalf("dump error: %s", err)
+	}
+
+	if out.String() != expected {
+		t.Errorf("wrong pairs, expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	expected := `x - id
+{ - erro - comentario nao fechado
+`
+
+	var out bytes.Buffer
+
+	if err := Dump(lexer.InitializeLexer("x {never\nclosed"), PAIR_FORMAT, &out); nil != err {
+		t.Fatalf("dump error: %s", err)
+	}
+
+	if out.String() != expected {
+		t.Errorf("wrong pairs, expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestLexicalErrors(t *testing.T) {
+	expected := `x - id
+:= - simb_atribuicao
+12abc - erro - numero mal formado
+1. - erro - numero mal formado
+umidentificadormuitograndemesmoparaalalg - erro - identificador muito grande
+99999999999999999999 - erro - numero fora do intervalo
+ação - id
+` + "\xff - erro - codificacao de caractere invalida\n" + `'abc' - cadeia
+'x - erro - cadeia nao fechada
+`
+
+	var out bytes.Buffer
+
+	if err := Dump(lexer.InitializeLexer("x := 12abc 1.\numidentificadormuitograndemesmoparaalalg 99999999999999999999 ação \xff 'abc' 'x"), PAIR_FORMAT, &out); nil != err {
+		t.Fatalf("dump error: %s", err)
+	}
+
+	if out.String() != expected {
+		t.Errorf("wrong pairs, expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestTableFormat(t *testing.T) {
+	expected := `POSITION   TYPE                 CLASS                  LITERAL
+1:1        IDENTIFIER           id                     x
+1:3        :=                   simb_atribuicao        :=
+1:6        REAL                 num_real               2.5
+1:10       ILLEGAL              erro                   !  (illegal character "!")
+2:1        WRITELN              writeln                writeln
+`
+
+	var out bytes.Buffer
+
+	if err := Dump(lexer.InitializeLexer("x := 2.5 !\nwriteln"), TABLE_FORMAT, &out); nil != err {
+		t.Fatalf("dump error: %s", err)
+	}
+
+	if out.String() != expected {
+		t.Errorf("wrong table, expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	expected := `{"type":"IDENTIFIER","class":"id","literal":"x","line":1,"column":1,"offset":0}
+{"type":"ILLEGAL","class":"erro","literal":"@","line":2,"column":2,"offset":3,"error":"illegal character \"@\""}
+`
+
+	var out bytes.Buffer
+
+	if err := Dump(lexer.InitializeLexer("x\n @"), JSON_FORMAT, &out); nil != err {
+		t.Fatalf("dump error: %s", err)
+	}
+
+	if out.String() != expected {
+		t.Errorf("wrong json, expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+
+	if err := Dump(lexer.InitializeLexer("x"), Format("xml"), &out); nil == err {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
+
+// countingReader : tells how much of the input was read
+type countingReader struct {
+	in   io.Reader
+	read int
+}
+
+// Read :
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.in.Read(p)
+	r.read += n
+
+	return n, err
+}
+
+// failingWriter : fails every write
+type failingWriter struct{}
+
+// Write :
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, fmt.Errorf("disk full")
+}
+
+// TestStreaming : every entry is written as soon as it is read, the rest of the input left alone when writing fails
+func TestStreaming(t *testing.T) {
+	input := strings.Repeat("a := a + 1;\n", 100000)
+	in := &countingReader{in: strings.NewReader(input)}
+
+	err := Dump(lexer.InitializeLexerFromReader(in), PAIR_FORMAT, failingWriter{})
+
+	if nil == err || "disk full" != err.Error() {
+		t.Fatalf("expected the write error, got=%v", err)
+	}
+
+	if in.read >= len(input) {
+		t.Errorf("the whole input was read before writing, %d bytes", in.read)
+	}
+}
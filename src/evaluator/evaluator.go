@@ -0,0 +1,439 @@
+package evaluator
+
+import (
+	"fmt"
+	"io"
+
+	"../ast"
+	"../object"
+	"../token"
+)
+
+// MAX_DEPTH : calls that may be active at once, the same limit the virtual machine has on its frames
+const MAX_DEPTH = 1024
+
+// Evaluator : tree-walking interpreter, read and write statements use the given input and output
+type Evaluator struct {
+	in         *object.Input
+	out        io.Writer
+	arithmetic object.Arithmetic
+	// calls active at the moment
+	depth int
+}
+
+// newError :
+func newError(tok token.Token, format string, a ...interface{}) *object.Error {
+	return &object.Error{
+		Message:  fmt.Sprintf(format, a...),
+		Position: tok.Position,
+	}
+}
+
+// isError :
+func isError(obj object.Object) bool {
+	return nil != obj && object.ERROR_OBJ == obj.Type()
+}
+
+// Eval :
+func (e *Evaluator) Eval(node ast.Node, env *object.Environment) object.Object {
+	switch node := node.(type) {
+	case *ast.Program:
+		return e.evalStatements(node.Statements, env)
+	case *ast.ExpressionStatement:
+		return e.Eval(node.Expression, env)
+	case *ast.BlockStatement:
+		return e.evalStatements(node.Statements, env)
+	case *ast.VarStatement:
+		return e.evalDeclaration(node.Token, node.Name, node.Type, node.Value, false, env)
+	case *ast.ConstStatement:
+		return e.evalDeclaration(node.Token, node.Name, node.Type, node.Value, true, env)
+	case *ast.AssignStatement:
+		return e.evalAssignStatement(node, env)
+	case *ast.ReadStatement:
+		return e.evalReadStatement(node, env)
+	case *ast.WriteStatement:
+		return e.evalWriteStatement(node, env)
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
+	case *ast.IntegerLiteral:
+		return &object.Integer{Value: node.Value}
+	case *ast.RealLiteral:
+		return &object.Real{Value: node.Value}
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+	case *ast.PrefixExpression:
+		return e.evalPrefixExpression(node, env)
+	case *ast.InfixExpression:
+		return e.evalInfixExpression(node, env)
+	case *ast.ConditionalExpression:
+		return e.evalConditionalExpression(node, env)
+	case *ast.WhileLiteral:
+		return e.evalWhileLiteral(node, env)
+	case *ast.ForLiteral:
+		return e.evalForLiteral(node, env)
+	case *ast.ProcedureLiteral:
+		return evalProcedureLiteral(node, env)
+	case *ast.CallExpression:
+		return e.evalCallExpression(node, env)
+	case *ast.ProgramLiteral:
+		return e.evalProgramLiteral(node, env)
+	}
+
+	return object.NULL
+}
+
+// evalStatements : value of the last statement, stopping at the first error
+func (e *Evaluator) evalStatements(statements []ast.Statement, env *object.Environment) object.Object {
+	var result object.Object = object.NULL
+
+	for _, statement := range statements {
+		result = e.Eval(statement, env)
+
+		if isError(result) {
+			return result
+		}
+	}
+
+	return result
+}
+
+// evalDeclaration : var and const statements, a missing value defaults to the zero of the declared type
+func (e *Evaluator) evalDeclaration(tok token.Token, name *ast.Identifier, t token.Token, value ast.Expression, constant bool, env *object.Environment) object.Object {
+	zero := object.Zero(t.Type)
+	var result object.Object = zero
+
+	if nil != value {
+		result = e.Eval(value, env)
+
+		if isError(result) {
+			return result
+		}
+	}
+
+	converted, err := object.Convert(zero, result)
+
+	if nil != err {
+		return newError(tok, "cannot declare %s: %s", name.Value, err)
+	}
+
+	env.Declare(name.Value, converted, constant)
+
+	return object.NULL
+}
+
+// evalAssignStatement :
+func (e *Evaluator) evalAssignStatement(node *ast.AssignStatement, env *object.Environment) object.Object {
+	value := e.Eval(node.Value, env)
+
+	if isError(value) {
+		return value
+	}
+
+	if _, err := env.Assign(node.Name.Value, value); nil != err {
+		return newError(node.Token, "%s", err)
+	}
+
+	return object.NULL
+}
+
+// evalReadStatement : each word read is parsed according to the type of the variable it is stored in
+func (e *Evaluator) evalReadStatement(node *ast.ReadStatement, env *object.Environment) object.Object {
+	for _, argument := range node.Arguments {
+		current, ok := env.Get(argument.Value)
+
+		if !ok {
+			return newError(argument.Token, "identifier not found: %s", argument.Value)
+		}
+
+		value, err := e.in.Read(current)
+
+		if nil != err {
+			return newError(node.Token, "could not read %s: %s", argument.Value, err)
+		}
+
+		if _, err := env.Assign(argument.Value, value); nil != err {
+			return newError(node.Token, "%s", err)
+		}
+	}
+
+	if token.READLN == node.Token.Type {
+		e.in.SkipLine()
+	}
+
+	return object.NULL
+}
+
+// evalWriteStatement : arguments are written one after the other, as Pascal does
+func (e *Evaluator) evalWriteStatement(node *ast.WriteStatement, env *object.Environment) object.Object {
+	for _, argument := range node.Arguments {
+		value := e.Eval(argument, env)
+
+		if isError(value) {
+			return value
+		}
+
+		io.WriteString(e.out, value.Inspect())
+	}
+
+	if token.WRITELN == node.Token.Type {
+		io.WriteString(e.out, "\n")
+	}
+
+	return object.NULL
+}
+
+// evalIdentifier :
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if value, ok := env.Get(node.Value); ok {
+		return value
+	}
+
+	return newError(node.Token, "identifier not found: %s", node.Value)
+}
+
+// evalPrefixExpression :
+func (e *Evaluator) evalPrefixExpression(node *ast.PrefixExpression, env *object.Environment) object.Object {
+	right := e.Eval(node.Right, env)
+
+	if isError(right) {
+		return right
+	}
+
+	result, err := e.arithmetic.Unary(node.Token.Type, right)
+
+	if nil != err {
+		return newError(node.Token, "%s", err)
+	}
+
+	return result
+}
+
+// evalInfixExpression :
+func (e *Evaluator) evalInfixExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := e.Eval(node.Left, env)
+
+	if isError(left) {
+		return left
+	}
+
+	right := e.Eval(node.Right, env)
+
+	if isError(right) {
+		return right
+	}
+
+	result, err := e.arithmetic.Binary(node.Token.Type, left, right)
+
+	if nil != err {
+		return newError(node.Token, "%s", err)
+	}
+
+	return result
+}
+
+// evalCondition :
+func (e *Evaluator) evalCondition(tok token.Token, condition ast.Expression, env *object.Environment) (bool, object.Object) {
+	value := e.Eval(condition, env)
+
+	if isError(value) {
+		return false, value
+	}
+
+	result, err := object.Condition(value)
+
+	if nil != err {
+		return false, newError(tok, "%s", err)
+	}
+
+	return result, nil
+}
+
+// evalConditionalExpression :
+func (e *Evaluator) evalConditionalExpression(node *ast.ConditionalExpression, env *object.Environment) object.Object {
+	condition, failure := e.evalCondition(node.Token, node.Condition, env)
+
+	if nil != failure {
+		return failure
+	}
+
+	if condition {
+		return e.Eval(node.Consequence, env)
+	}
+
+	if nil != node.Alternative {
+		return e.Eval(node.Alternative, env)
+	}
+
+	return object.NULL
+}
+
+// evalWhileLiteral :
+func (e *Evaluator) evalWhileLiteral(node *ast.WhileLiteral, env *object.Environment) object.Object {
+	for {
+		condition, failure := e.evalCondition(node.Token, node.Condition, env)
+
+		if nil != failure {
+			return failure
+		}
+
+		if !condition {
+			return object.NULL
+		}
+
+		if result := e.Eval(node.Body, env); isError(result) {
+			return result
+		}
+	}
+}
+
+// evalBound : for loop bounds must be integers
+func (e *Evaluator) evalBound(tok token.Token, bound ast.Expression, env *object.Environment) object.Object {
+	value := e.Eval(bound, env)
+
+	if isError(value) {
+		return value
+	}
+
+	if object.INTEGER_OBJ != value.Type() {
+		return newError(tok, "for bound is not an integer, got %s", value.Type())
+	}
+
+	return value
+}
+
+// evalForLiteral : both bounds are evaluated once, before the first iteration
+func (e *Evaluator) evalForLiteral(node *ast.ForLiteral, env *object.Environment) object.Object {
+	from := e.evalBound(node.Token, node.From, env)
+
+	if isError(from) {
+		return from
+	}
+
+	to := e.evalBound(node.Token, node.To, env)
+
+	if isError(to) {
+		return to
+	}
+
+	last := to.(*object.Integer).Value
+
+	// stops at the last value rather than past it, which would wrap around when it is the largest integer
+	for i := from.(*object.Integer).Value; i <= last; i++ {
+		if _, err := env.Assign(node.Variable.Value, &object.Integer{Value: i}); nil != err {
+			return newError(node.Variable.Token, "%s", err)
+		}
+
+		if result := e.Eval(node.Body, env); isError(result) {
+			return result
+		}
+
+		if i == last {
+			break
+		}
+	}
+
+	return object.NULL
+}
+
+// evalProcedureLiteral :
+func evalProcedureLiteral(node *ast.ProcedureLiteral, env *object.Environment) object.Object {
+	procedure := &object.Procedure{
+		Name:         node.Name,
+		Parameters:   node.Parameters,
+		Declarations: node.Declarations,
+		Body:         node.Body,
+		Env:          env,
+	}
+
+	env.Declare(node.Name, procedure, true)
+
+	return object.NULL
+}
+
+// evalCallExpression : arguments are passed by value into a scope enclosed by the one the procedure was declared in
+func (e *Evaluator) evalCallExpression(node *ast.CallExpression, env *object.Environment) object.Object {
+	callee := e.Eval(node.Procedure, env)
+
+	if isError(callee) {
+		return callee
+	}
+
+	procedure, ok := callee.(*object.Procedure)
+
+	if !ok {
+		return newError(node.Token, "not a procedure: %s", callee.Type())
+	}
+
+	if len(procedure.Parameters) != len(node.Arguments) {
+		return newError(node.Token, "wrong number of arguments for %s: want=%d, got=%d", procedure.Name, len(procedure.Parameters), len(node.Arguments))
+	}
+
+	// runaway recursion is a runtime error rather than a crash of the Go stack
+	if MAX_DEPTH <= e.depth {
+		return newError(node.Token, "stack overflow")
+	}
+
+	e.depth++
+	defer func() {
+		e.depth--
+	}()
+
+	scope := object.InitializeEnclosedEnvironment(procedure.Env)
+
+	for i, parameter := range procedure.Parameters {
+		argument := e.Eval(node.Arguments[i], env)
+
+		if isError(argument) {
+			return argument
+		}
+
+		value, err := object.Convert(object.Zero(parameter.Type.Type), argument)
+
+		if nil != err {
+			return newError(node.Token, "cannot pass argument %s of %s: %s", parameter.Value, procedure.Name, err)
+		}
+
+		scope.Declare(parameter.Value, value, false)
+	}
+
+	if result := e.evalStatements(procedure.Declarations, scope); isError(result) {
+		return result
+	}
+
+	if result := e.Eval(procedure.Body, scope); isError(result) {
+		return result
+	}
+
+	return object.NULL
+}
+
+// evalProgramLiteral :
+func (e *Evaluator) evalProgramLiteral(node *ast.ProgramLiteral, env *object.Environment) object.Object {
+	if result := e.evalStatements(node.Declarations, env); isError(result) {
+		return result
+	}
+
+	for _, procedure := range node.Procedures {
+		evalProcedureLiteral(procedure, env)
+	}
+
+	if result := e.Eval(node.Body, env); isError(result) {
+		return result
+	}
+
+	return object.NULL
+}
+
+// InitializeEvaluator :
+func InitializeEvaluator(in io.Reader, out io.Writer) *Evaluator {
+	return InitializeEvaluatorWithIntegerWidth(in, out, object.INTEGER_WIDTH)
+}
+
+// InitializeEvaluatorWithIntegerWidth : integer results that do not fit in width bits are runtime errors
+func InitializeEvaluatorWithIntegerWidth(in io.Reader, out io.Writer, width int) *Evaluator {
+	return &Evaluator{
+		in:         object.InitializeInput(in, width),
+		out:        out,
+		arithmetic: object.Arithmetic{Width: width},
+	}
+}
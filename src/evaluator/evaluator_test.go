@@ -0,0 +1,343 @@
+package evaluator
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"../lexer"
+	"../object"
+	"../parser"
+)
+
+// testEval : evaluated object and everything written while evaluating
+func testEval(t *testing.T, input string, stdin string) (object.Object, string) {
+	l := lexer.InitializeLexer(input)
+	p := parser.InitializeParser(l)
+	program := p.ParseProgram()
+
+	if 0 != len(p.Errors()) {
+		t.Fatalf("parser errors for %q: %q", input, p.Errors())
+	}
+
+	var out bytes.Buffer
+
+	e := InitializeEvaluator(strings.NewReader(stdin), &out)
+	env := object.InitializeEnvironment()
+
+	return e.Eval(program, env), out.String()
+}
+
+// testIntegerObject :
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
+
+	if !ok {
+		t.Errorf("object is not Integer, got=%T (%+v)", obj, obj)
+
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value, expected=%d, got=%d", expected, result.Value)
+
+		return false
+	}
+
+	return true
+}
+
+// testRealObject :
+func testRealObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Real)
+
+	if !ok {
+		t.Errorf("object is not Real, got=%T (%+v)", obj, obj)
+
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value, expected=%f, got=%f", expected, result.Value)
+
+		return false
+	}
+
+	return true
+}
+
+// testBooleanObject :
+func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
+	result, ok := obj.(*object.Boolean)
+
+	if !ok {
+		t.Errorf("object is not Boolean, got=%T (%+v)", obj, obj)
+
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value, expected=%t, got=%t", expected, result.Value)
+
+		return false
+	}
+
+	return true
+}
+
+// TestEvalNumberExpressions :
+func TestEvalNumberExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"5", 5},
+		{"-10", -10},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * (5 + 10)", 30},
+		{"-50 + 100 + -50", 0},
+		{"7 div 2", 3},
+		{"7 mod 2", 1},
+		{"7 / 2", 3.5},
+		{"6 / 3", 2.0},
+		{"1.5 + 1", 2.5},
+		{"2 * 0.25", 0.5},
+		{"-1.5", -1.5},
+		{"-9223372036854775808", math.MinInt64},
+		{"-9223372036854775807 - 1", math.MinInt64},
+	}
+
+	for _, tt := range tests {
+		evaluated, _ := testEval(t, tt.input, "")
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testRealObject(t, evaluated, expected)
+		}
+	}
+}
+
+// TestEvalBooleanExpressions :
+func TestEvalBooleanExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 <= 1", true},
+		{"1 >= 2", false},
+		{"1 = 1", true},
+		{"1 == 2", false},
+		{"1 <> 2", true},
+		{"1.5 > 1", true},
+		{"(1 < 2) and (2 < 3)", true},
+		{"(1 < 2) and (2 > 3)", false},
+		{"(1 > 2) or (2 < 3)", true},
+		{"not (1 < 2)", false},
+		{"(1 < 2) = (3 < 4)", true},
+	}
+
+	for _, tt := range tests {
+		evaluated, _ := testEval(t, tt.input, "")
+
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+// TestDeclarationsAndAssignments :
+func TestDeclarationsAndAssignments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"var a: integer := 5; a;", 5},
+		{"var a: integer; a;", 0},
+		{"var a: real := 5; a;", 5.0},
+		{"var a: integer := 5; a := a * 2; a;", 10},
+		{"var a: real; a := 3; a;", 3.0},
+		{"const a: integer := 5; var b: integer := a + 1; b;", 6},
+	}
+
+	for _, tt := range tests {
+		evaluated, _ := testEval(t, tt.input, "")
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testRealObject(t, evaluated, expected)
+		}
+	}
+}
+
+// TestErrorHandling :
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"foobar", "1:1: runtime error: identifier not found: foobar"},
+		{"1 div 0", "1:3: runtime error: division by zero"},
+		{"1.5 div 2", "1:5: runtime error: unknown operator: REAL DIV REAL"},
+		{"var a: integer := 2.5;", "1:1: runtime error: cannot declare a: type mismatch: REAL to INTEGER"},
+		{"var a: integer; a := 0.5;", "1:19: runtime error: cannot assign to a: type mismatch: REAL to INTEGER"},
+		{"const a: integer := 1; a := 2;", "1:26: runtime error: cannot assign to constant a"},
+		{"if 1 then a end", "1:1: runtime error: condition is not a boolean, got INTEGER"},
+		{"procedure p(a: integer); begin end; p(1, 2)", "1:38: runtime error: wrong number of arguments for p: want=1, got=2"},
+		{"var x: integer; x(1)", "1:18: runtime error: not a procedure: INTEGER"},
+		{"not 1", "1:1: runtime error: unknown operator: NOT INTEGER"},
+		{"var a: integer := 'a';", "1:1: runtime error: cannot declare a: type mismatch: STRING to INTEGER"},
+		{"9223372036854775807 + 1", "1:21: runtime error: integer overflow, 9223372036854775807 + 1 does not fit in 64 bits"},
+		{"-9223372036854775808 - 1", "1:22: runtime error: integer overflow, -9223372036854775808 - 1 does not fit in 64 bits"},
+		{"4294967296 * 4294967296", "1:12: runtime error: integer overflow, 4294967296 * 4294967296 does not fit in 64 bits"},
+		{"-1 * -9223372036854775808", "1:4: runtime error: integer overflow, -1 * -9223372036854775808 does not fit in 64 bits"},
+		{"-9223372036854775808 div -1", "1:22: runtime error: integer overflow, -9223372036854775808 div -1 does not fit in 64 bits"},
+		{"-(-9223372036854775808)", "1:1: runtime error: integer overflow, -(-9223372036854775808) does not fit in 64 bits"},
+		{"procedure f(k: integer); begin f(k + 1) end; f(0)", "1:33: runtime error: stack overflow"},
+	}
+
+	for _, tt := range tests {
+		evaluated, _ := testEval(t, tt.input, "")
+
+		failure, ok := evaluated.(*object.Error)
+
+		if !ok {
+			t.Errorf("no error object returned for %q, got=%T (%+v)", tt.input, evaluated, evaluated)
+
+			continue
+		}
+
+		if failure.Inspect() != tt.expectedMessage {
+			t.Errorf("wrong error message, expected=%q, got=%q", tt.expectedMessage, failure.Inspect())
+		}
+	}
+}
+
+// TestProgramExecution :
+func TestProgramExecution(t *testing.T) {
+	tests := []struct {
+		input    string
+		stdin    string
+		expected string
+	}{
+		{
+			`program loops;
+var i: integer;
+var total: integer := 0;
+begin
+	for i := 1 to 10 do
+		total := total + i;
+	writeln(total);
+	while total > 40 do
+		begin
+			total := total - 7;
+			write(total);
+		end;
+	writeln
+end.`,
+			"",
+			"55\n484134\n",
+		},
+		{
+			`program conditionals;
+var a: integer;
+var b: real;
+begin
+	readln(a);
+	read(b);
+	if a > b then writeln(a) end else writeln(b) end;
+	if a = 3 then writeln(a * b) end
+end.`,
+			"3 ignored\n2.5\n",
+			"3\n7.5\n",
+		},
+		{
+			`program procedures;
+var counter: integer := 0;
+procedure increment(by: integer);
+	var half: real;
+	begin
+		half := by / 2;
+		counter := counter + by;
+		writeln(half)
+	end;
+procedure twice(by: integer);
+	begin
+		increment(by);
+		increment(by)
+	end;
+begin
+	twice(3);
+	writeln(counter)
+end.`,
+			"",
+			"1.5\n1.5\n6\n",
+		},
+		{
+			`program shadowing;
+var x: integer := 1;
+procedure show(x: real);
+	begin
+		writeln(x)
+	end;
+begin
+	show(x + 1);
+	writeln(x)
+end.`,
+			"",
+			"2.0\n1\n",
+		},
+		{
+			`program strings;
+var x: integer := 3;
+begin
+	writeln('x = ', x, '; it''s ', x * 2.5, '');
+	write('''')
+end.`,
+			"",
+			"x = 3; it's 7.5\n'",
+		},
+		{
+			`program blocks;
+var t: integer := 0;
+procedure f(k: integer);
+	begin
+		if k > 2 then writeln(k) end else begin t := k + 1; f(t) end end
+	end;
+begin
+	f(0);
+	begin
+		t := t * 2;
+		writeln(t)
+	end
+end.`,
+			"",
+			"3\n6\n",
+		},
+		{
+			`program largest;
+var i: integer;
+begin
+	for i := 9223372036854775806 to 9223372036854775807 do write(i, ' ')
+end.`,
+			"",
+			"9223372036854775806 9223372036854775807 ",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated, output := testEval(t, tt.input, tt.stdin)
+
+		if failure, ok := evaluated.(*object.Error); ok {
+			t.Fatalf("runtime error: %s", failure.Inspect())
+		}
+
+		if output != tt.expected {
+			t.Errorf("wrong output, expected=%q, got=%q", tt.expected, output)
+		}
+	}
+}
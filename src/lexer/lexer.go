@@ -1,121 +1,349 @@
 package lexer
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"../diagnostic"
 	"../token"
 )
 
-// Lexer :
+const (
+	// MAX_IDENTIFIER_LENGTH : default limit, longer identifiers are still returned but reported
+	MAX_IDENTIFIER_LENGTH = 32
+	// INTEGER_WIDTH : default bits of an integer, the same object.INTEGER_WIDTH the evaluator and the virtual machines use
+	INTEGER_WIDTH = 64
+)
+
+// Options : lexical rules that change from one LALG dialect to another
+type Options struct {
+	// Whether a "{" inside a comment opens a nested one, standard Pascal closes the comment at the first "}"
+	NestedComments bool
+	// Longest identifier accepted, zero meaning no limit at all
+	MaxIdentifierLength int
+	// Bits of the target integers, 16, 32 or 64, larger literals being reported; zero means INTEGER_WIDTH
+	IntegerWidth int
+	// Whether the comments are kept for Comments(), which only the printer needs; otherwise their text is dropped as
+	// soon as they end, so a long input is not held in memory comment by comment
+	KeepComments bool
+}
+
+// Lexer : decodes the UTF-8 input through a buffered window, only the text of the current token is kept in memory
 type Lexer struct {
-	input string
-	// current position in input (points to current char)
+	reader *bufio.Reader
+	// first error other than io.EOF returned by the reader, the input ending there
+	err error
+	// current position in input, in bytes (points to current char)
 	position int
-	// current reading position in input (after current char)
+	// current reading position in input, in bytes (after current char)
 	readPosition int
 	// current char under examination
-	char byte
+	char rune
+	// bytes of the current char as found in the input, none past its end
+	raw   [utf8.UTFMax]byte
+	width int
+	// chars read since the last mark, the current one excluded
+	text []byte
+	// whether the chars read are left out of text, as those of the comments that are not kept
+	discarding bool
+	// line and column of the current char, both starting at one
+	line   int
+	column int
+
+	options     Options
+	comments    []token.Token
+	diagnostics diagnostic.List
 }
 
-// isLetter : maybe PLUS '?' and '!' as valid also in a near future -- R doesn't allow it
-func isLetter(char byte) bool {
-	return (('a' <= char && char <= 'z') || ('A' <= char && char <= 'Z') || char == '_') && char != ':'
+// isLetter : any Unicode letter, so Portuguese names like "soma_média" are identifiers too
+func isLetter(char rune) bool {
+	return unicode.IsLetter(char) || '_' == char
 }
 
-// isDigit :
-func isDigit(char byte) bool {
+// isDigit : numbers are written with ASCII digits only
+func isDigit(char rune) bool {
 	return '0' <= char && char <= '9'
 }
 
 // peekChar :
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+func (l *Lexer) peekChar() rune {
+	if nil != l.err {
+		return 0
+	}
+
+	// fewer bytes than asked for are returned near the end of the input
+	next, _ := l.reader.Peek(utf8.UTFMax)
+
+	if 0 == len(next) {
 		return 0
 	}
 
-	return l.input[l.readPosition]
+	char, _ := utf8.DecodeRune(next)
+
+	return char
 }
 
 // readChar :
 func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		l.char = 0
-	} else {
-		l.char = l.input[l.readPosition]
+	if '\n' == l.char {
+		l.line++
+		l.column = 0
+	}
+
+	// ASCII is by far the most common, one byte is appended without going through a slice
+	if !l.discarding {
+		if 1 == l.width {
+			l.text = append(l.text, l.raw[0])
+		} else {
+			l.text = append(l.text, l.raw[:l.width]...)
+		}
+	}
+
+	l.char, l.width = 0, 0
+
+	if nil == l.err {
+		l.decode()
 	}
 
 	l.position = l.readPosition
-	l.readPosition++
+	l.readPosition += l.width
+	l.column++
+
+	// keeps moving past the end, as every char there is counted as one
+	if 0 == l.width {
+		l.readPosition++
+	}
+}
+
+// decode : reads the next rune, bytes that are not valid UTF-8 being read one at a time
+func (l *Lexer) decode() {
+	char, width, err := l.reader.ReadRune()
+
+	if nil != err {
+		if io.EOF != err {
+			l.err = err
+		}
+
+		return
+	}
+
+	l.char, l.width = char, width
+
+	if l.invalid() {
+		l.reader.UnreadRune()
+		l.raw[0], _ = l.reader.ReadByte()
+
+		return
+	}
+
+	utf8.EncodeRune(l.raw[:], char)
+}
+
+// invalid : whether the current char is a byte that is not valid UTF-8, rather than an actual U+FFFD
+func (l *Lexer) invalid() bool {
+	return utf8.RuneError == l.char && 1 == l.width
+}
+
+// mark : starts recording the text of a token at the current char
+func (l *Lexer) mark() {
+	l.text = l.text[:0]
+}
+
+// marked : text read since the last mark, up to but not including the current char
+func (l *Lexer) marked() string {
+	return string(l.text)
+}
+
+// currentPosition : where the current char is located
+func (l *Lexer) currentPosition() token.Position {
+	return token.Position{
+		Line:   l.line,
+		Column: l.column,
+		Offset: l.position,
+	}
 }
 
 // readIt :
-func readIt(l *Lexer, isIt func(char byte) bool) string {
-	position := l.position
+func readIt(l *Lexer, isIt func(char rune) bool) string {
+	l.mark()
 
 	for isIt(l.char) {
 		l.readChar()
 	}
 
-	return l.input[position:l.position]
+	return l.marked()
 }
 
-// readIdentifier :
+// readIdentifier : a letter followed by letters and digits
 func (l *Lexer) readIdentifier() string {
-	return readIt(l, isLetter)
+	return readIt(l, func(char rune) bool {
+		return isLetter(char) || isDigit(char)
+	})
 }
 
-// readString :
-func (l *Lexer) readString() string {
-	postion := l.position + 1
+// readString : "'abc'", a quote written twice standing for a single one; the literal keeps the quotes as written and
+// strings, like in Pascal, end on the line they started
+func (l *Lexer) readString() token.Token {
+	tok := token.Token{
+		Type:     token.STRING,
+		Position: l.currentPosition(),
+	}
+
+	l.mark()
+	l.readChar()
 
 	for {
-		l.readChar()
+		switch l.char {
+		case '\'':
+			l.readChar()
 
-		if '"' == l.char || 0 == l.char {
-			break
+			if '\'' != l.char {
+				tok.Literal = l.marked()
+
+				return tok
+			}
+		case '\n', 0:
+			tok.Type = token.ILLEGAL
+			tok.Literal = l.marked()
+			l.report(diagnostic.UNTERMINATED_STRING, tok, "unterminated string")
+
+			return tok
 		}
-	}
 
-	return l.input[postion:l.position]
+		l.readChar()
+	}
 }
 
 // newToken :
-func newToken(tokenType token.TokenType, char byte) token.Token {
+func newToken(tokenType token.TokenType, char rune) token.Token {
 	return token.Token{
 		Type:    tokenType,
 		Literal: string(char),
 	}
 }
 
-// readNumber :
-func (l *Lexer) readNumber() token.Token {
-	var isReal bool
-	position := l.position
+// readDigits : decimal digits, a "_" being allowed only between two of them, telling whether the run is malformed
+func (l *Lexer) readDigits() bool {
+	malformed := !isDigit(l.char)
+
+	for isDigit(l.char) || '_' == l.char {
+		if '_' == l.char && !isDigit(l.peekChar()) {
+			malformed = true
+		}
 
-	for isDigit(l.char) {
 		l.readChar()
 	}
 
+	return malformed
+}
+
+// readNumber : "1_000", "2.5" or "1.5e-3", malformed and out of range numbers become a single ILLEGAL token
+func (l *Lexer) readNumber() token.Token {
+	tok := token.Token{
+		Type:     token.INTEGER,
+		Position: l.currentPosition(),
+	}
+
+	l.mark()
+
+	malformed := l.readDigits()
+
+	// "12." has no fractional part
 	if '.' == l.char {
-		isReal = true
+		tok.Type = token.REAL
+		l.readChar()
+		malformed = l.readDigits() || malformed
+	}
+
+	// "1e" has no exponent
+	if 'e' == l.char || 'E' == l.char {
+		tok.Type = token.REAL
 		l.readChar()
 
-		for isDigit(l.char) {
+		if '+' == l.char || '-' == l.char {
 			l.readChar()
 		}
+
+		malformed = l.readDigits() || malformed
 	}
 
-	number := l.input[position:l.position]
+	// letters glued to the number, as in "12abc" or "1.a23", are part of the same mistake
+	if isLetter(l.char) {
+		malformed = true
 
-	if isReal {
-		return token.Token{
-			Type:    token.REAL,
-			Literal: number,
+		for isLetter(l.char) || isDigit(l.char) {
+			l.readChar()
 		}
 	}
 
-	return token.Token{
-		Type:    token.INTEGER,
-		Literal: number,
+	tok.Literal = l.marked()
+
+	if malformed {
+		tok.Type = token.ILLEGAL
+		l.report(diagnostic.MALFORMED_NUMBER, tok, fmt.Sprintf("malformed number %q", tok.Literal))
+
+		return tok
+	}
+
+	if message := l.outOfRange(tok); "" != message {
+		tok.Type = token.ILLEGAL
+		l.report(diagnostic.NUMBER_OUT_OF_RANGE, tok, message)
+	}
+
+	return tok
+}
+
+// outOfRange : why the number does not fit in the values of its type, empty when it does
+func (l *Lexer) outOfRange(tok token.Token) string {
+	number := strings.Replace(tok.Literal, "_", "", -1)
+
+	if token.REAL == tok.Type {
+		if _, err := strconv.ParseFloat(number, 64); nil != err {
+			return fmt.Sprintf("number out of range %q", tok.Literal)
+		}
+
+		return ""
+	}
+
+	width := l.IntegerWidth()
+
+	// "32768" fits in 16 bits only after a unary minus, which the parser tells apart from a binary one
+	if MostNegative(tok.Literal, width) {
+		return ""
+	}
+
+	if _, err := strconv.ParseInt(number, 10, width); nil != err {
+		return OutOfRange(tok.Literal, width)
 	}
+
+	return ""
+}
+
+// MostNegative : whether the digits of the integer literal are those of the most negative integer of the width, which
+// does not fit without its sign
+func MostNegative(literal string, width int) bool {
+	value, err := strconv.ParseUint(strings.Replace(literal, "_", "", -1), 10, 64)
+
+	return nil == err && uint64(1)<<uint(width-1) == value
+}
+
+// OutOfRange : message of an integer literal too large for the width
+func OutOfRange(literal string, width int) string {
+	return fmt.Sprintf("number out of range %q, the largest %d-bit integer is %d", literal, width, int64(1)<<uint(width-1)-1)
+}
+
+// IntegerWidth : bits of the integers the literals must fit in
+func (l *Lexer) IntegerWidth() int {
+	if 0 == l.options.IntegerWidth {
+		return INTEGER_WIDTH
+	}
+
+	return l.options.IntegerWidth
 }
 
 // newPeekedToken :
@@ -131,19 +359,128 @@ func newPeekedToken(l *Lexer, t token.TokenType) token.Token {
 	}
 }
 
-// skipWhitespace :
+// skipWhitespace : comments count as whitespace, they are kept apart from the tokens
 func (l *Lexer) skipWhitespace() {
-	for l.char == ' ' || l.char == '\t' || l.char == '\n' || l.char == '\r' {
+	for {
+		switch l.char {
+		case ' ', '\t', '\n', '\r':
+			l.mark()
+			l.readChar()
+		case '{':
+			l.readComment()
+		default:
+			return
+		}
+	}
+}
+
+// readComment : "{ ... }", the whole text, braces included, becomes the literal of a COMMENT token
+func (l *Lexer) readComment() {
+	comment := token.Token{
+		Type:     token.COMMENT,
+		Position: l.currentPosition(),
+	}
+	depth := 0
+
+	l.mark()
+
+	// a comment of several megabytes is not held in memory only to be thrown away
+	l.discarding = !l.options.KeepComments
+
+	defer func() {
+		l.discarding = false
+	}()
+
+	for {
+		switch l.char {
+		case '{':
+			if 0 == depth || l.options.NestedComments {
+				depth++
+			}
+		case '}':
+			depth--
+		case 0:
+			l.keepComment(comment)
+			l.unterminatedComment(comment)
+
+			return
+		}
+
 		l.readChar()
+
+		if 0 == depth {
+			l.keepComment(comment)
+
+			return
+		}
 	}
 }
 
+// keepComment : the comment read since the last mark, when the options ask for it
+func (l *Lexer) keepComment(comment token.Token) {
+	if !l.options.KeepComments {
+		return
+	}
+
+	comment.Literal = l.marked()
+	l.comments = append(l.comments, comment)
+}
+
+// unterminatedComment : reported at the opening brace, the comment running until the end of the input
+func (l *Lexer) unterminatedComment(comment token.Token) {
+	l.diagnostics = append(l.diagnostics, diagnostic.Diagnostic{
+		Severity: diagnostic.ERROR,
+		Code:     diagnostic.UNTERMINATED_COMMENT,
+		Start:    comment.Position,
+		End:      l.currentPosition(),
+		Found:    token.Token{Type: token.EOF, Position: l.currentPosition()},
+		Message:  "unterminated comment",
+	})
+}
+
+// Comments : comments skipped so far, in source order; always empty unless the options keep them
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
+// report : lexical error spanning the whole token
+func (l *Lexer) report(code diagnostic.Code, tok token.Token, message string) {
+	l.diagnostics = append(l.diagnostics, diagnostic.Diagnostic{
+		Severity: diagnostic.ERROR,
+		Code:     code,
+		Start:    tok.Position,
+		End:      tok.End(),
+		Found:    tok,
+		Message:  message,
+	})
+}
+
+// illegalCharacter :
+func (l *Lexer) illegalCharacter(tok token.Token) {
+	l.report(diagnostic.INVALID_CHARACTER, tok, fmt.Sprintf("illegal character %q", tok.Literal))
+}
+
+// Err : error that stopped reading the input early, nil when it was read until the end
+func (l *Lexer) Err() error {
+	return l.err
+}
+
+// Diagnostics : lexical errors found so far
+func (l *Lexer) Diagnostics() diagnostic.List {
+	return l.diagnostics
+}
+
 // NextToken :
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
 
+	// the text of the previous token is no longer needed, however long a run of single char tokens gets
+	l.mark()
+
+	position := l.currentPosition()
+
 	switch l.char {
 	case '+':
 		tok = newToken(token.PLUS, l.char)
@@ -157,16 +494,20 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.LEFT_PARENTHESIS, l.char)
 	case ')':
 		tok = newToken(token.RIGHT_PARENTHESIS, l.char)
-	case '{':
-		tok = newToken(token.LEFT_BRACES, l.char)
-	case '}':
-		tok = newToken(token.RIGHT_BRACES, l.char)
 	case ',':
 		tok = newToken(token.COMMA, l.char)
 	case ';':
 		tok = newToken(token.SEMICOLON, l.char)
+	case '.':
+		tok = newToken(token.DOT, l.char)
+	case '\'':
+		return l.readString()
 	case '>':
-		tok = newToken(token.GREATER_THAN, l.char)
+		if '=' == l.peekChar() {
+			tok = newPeekedToken(l, token.GREATER_THAN_EQUAL)
+		} else {
+			tok = newToken(token.GREATER_THAN, l.char)
+		}
 	case '<':
 		switch l.peekChar() {
 		case '>':
@@ -183,10 +524,11 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.COLON, l.char)
 		}
 	case '=':
+		// "=" is the LALG equality, "==" is still accepted for the code written before it
 		if '=' == l.peekChar() {
 			tok = newPeekedToken(l, token.EQUAL)
 		} else {
-			tok = newToken(token.ILLEGAL, l.char)
+			tok = newToken(token.EQUAL, l.char)
 		}
 	case 0:
 		tok.Literal = ""
@@ -195,23 +537,79 @@ func (l *Lexer) NextToken() token.Token {
 		if isLetter(l.char) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdentifier(tok.Literal)
+			tok.Position = position
+
+			// kept as an identifier so the parser goes on as if the name was fine
+			if length := utf8.RuneCountInString(tok.Literal); 0 < l.options.MaxIdentifierLength && l.options.MaxIdentifierLength < length {
+				l.report(diagnostic.IDENTIFIER_TOO_LONG, tok, fmt.Sprintf("identifier too long, %q has %d characters, the limit is %d", tok.Literal, length, l.options.MaxIdentifierLength))
+			}
 
 			return tok
 		} else if isDigit(l.char) {
 			return l.readNumber()
+		} else if l.invalid() {
+			tok = token.Token{
+				Type:     token.ILLEGAL,
+				Literal:  string(l.raw[:1]),
+				Position: position,
+			}
+			l.report(diagnostic.INVALID_UTF8, tok, fmt.Sprintf("invalid UTF-8 byte %q", tok.Literal))
+			l.readChar()
+
+			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.char)
 		}
 	}
 
+	if token.ILLEGAL == tok.Type {
+		tok.Position = position
+		l.illegalCharacter(tok)
+	}
+
 	l.readChar()
 
+	tok.Position = position
+
 	return tok
 }
 
+// DefaultOptions : the dialect described in the assignment
+func DefaultOptions() Options {
+	return Options{
+		MaxIdentifierLength: MAX_IDENTIFIER_LENGTH,
+		IntegerWidth:        INTEGER_WIDTH,
+	}
+}
+
 // InitializeLexer :
 func InitializeLexer(input string) *Lexer {
-	l := &Lexer{input: input}
+	return InitializeLexerWithOptions(input, DefaultOptions())
+}
+
+// InitializeLexerWithOptions :
+func InitializeLexerWithOptions(input string, options Options) *Lexer {
+	return InitializeLexerFromReaderWithOptions(strings.NewReader(input), options)
+}
+
+// InitializeLexerFromReader : same tokens and positions the string would give, without reading it all at once
+func InitializeLexerFromReader(in io.Reader) *Lexer {
+	return InitializeLexerFromReaderWithOptions(in, DefaultOptions())
+}
+
+// InitializeLexerFromReaderWithOptions :
+func InitializeLexerFromReaderWithOptions(in io.Reader, options Options) *Lexer {
+	reader, ok := in.(*bufio.Reader)
+
+	if !ok {
+		reader = bufio.NewReader(in)
+	}
+
+	l := &Lexer{
+		reader:  reader,
+		line:    1,
+		options: options,
+	}
 	l.readChar()
 
 	return l
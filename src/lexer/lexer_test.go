@@ -1,8 +1,13 @@
 package lexer
 
 import (
+	"errors"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 
+	"../diagnostic"
 	"../token"
 )
 
@@ -40,7 +45,7 @@ for y := 0 to 100 do
 		y := y + 1;
 		y := y * 2;
 	end
-end
+end.
 `
 
 	test := []struct {
@@ -107,11 +112,6 @@ end
 		{token.DIFFERENT, "<>"},
 		{token.INTEGER, "9"},
 		{token.SEMICOLON, ";"},
-		{token.LEFT_BRACES, "{"},
-		{token.IDENTIFIER, "just"},
-		{token.IDENTIFIER, "a"},
-		{token.IDENTIFIER, "comment"},
-		{token.RIGHT_BRACES, "}"},
 		{token.VAR, "var"},
 		{token.IDENTIFIER, "foo"},
 		{token.COLON, ":"},
@@ -126,12 +126,6 @@ end
 		{token.PROGRAM, "program"},
 		{token.IDENTIFIER, "main"},
 		{token.SEMICOLON, ";"},
-		{token.LEFT_BRACES, "{"},
-		{token.IDENTIFIER, "main"},
-		{token.IDENTIFIER, "example"},
-		{token.IDENTIFIER, "currently"},
-		{token.IDENTIFIER, "working"},
-		{token.RIGHT_BRACES, "}"},
 		{token.WHILE, "while"},
 		{token.LEFT_PARENTHESIS, "("},
 		{token.IDENTIFIER, "x"},
@@ -167,6 +161,7 @@ end
 		{token.SEMICOLON, ";"},
 		{token.END, "end"},
 		{token.END, "end"},
+		{token.DOT, "."},
 		{token.EOF, ""},
 	}
 
@@ -184,3 +179,650 @@ end
 		}
 	}
 }
+
+// TestTokenPosition :
+func TestTokenPosition(t *testing.T) {
+	input := `var x: integer := 10;
+	x := x <> 5;
+
+foo`
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+		expectedOffset  int
+	}{
+		{"var", 1, 1, 0},
+		{"x", 1, 5, 4},
+		{":", 1, 6, 5},
+		{"integer", 1, 8, 7},
+		{":=", 1, 16, 15},
+		{"10", 1, 19, 18},
+		{";", 1, 21, 20},
+		{"x", 2, 2, 23},
+		{":=", 2, 4, 25},
+		{"x", 2, 7, 28},
+		{"<>", 2, 9, 30},
+		{"5", 2, 12, 33},
+		{";", 2, 13, 34},
+		{"foo", 4, 1, 37},
+		{"", 4, 4, 40},
+	}
+
+	l := InitializeLexer(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong\n\texpected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Position.Line != tt.expectedLine || tok.Position.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong\n\texpected=%d:%d, got=%s", i, tt.expectedLine, tt.expectedColumn, tok.Position)
+		}
+
+		if tok.Position.Offset != tt.expectedOffset {
+			t.Fatalf("tests[%d] - offset wrong\n\texpected=%d, got=%d", i, tt.expectedOffset, tok.Position.Offset)
+		}
+	}
+}
+
+// TestIllegalCharacterDiagnostics :
+func TestIllegalCharacterDiagnostics(t *testing.T) {
+	input := "readd(a, @, 1);\nx ! 2"
+
+	l := InitializeLexer(input)
+
+	for tok := l.NextToken(); token.EOF != tok.Type; tok = l.NextToken() {
+	}
+
+	expected := []string{
+		"1:10: illegal character \"@\"",
+		"2:3: illegal character \"!\"",
+	}
+
+	diagnostics := l.Diagnostics()
+
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("wrong number of diagnostics, expected=%d, got=%d", len(expected), len(diagnostics))
+	}
+
+	for i, message := range expected {
+		if diagnostics[i].String() != message {
+			t.Errorf("diagnostics[%d] wrong, expected=%q, got=%q", i, message, diagnostics[i].String())
+		}
+
+		if diagnostic.INVALID_CHARACTER != diagnostics[i].Code {
+			t.Errorf("diagnostics[%d] code wrong, got=%s", i, diagnostics[i].Code)
+		}
+	}
+}
+
+// TestComments :
+func TestComments(t *testing.T) {
+	tests := []struct {
+		input            string
+		options          Options
+		expectedTokens   []string
+		expectedComments []string
+	}{
+		{"a {first} b{second}c", Options{KeepComments: true}, []string{"a", "b", "c"}, []string{"{first}", "{second}"}},
+		{"a {multi\nline\n} b", Options{KeepComments: true}, []string{"a", "b"}, []string{"{multi\nline\n}"}},
+		{"a {outer {inner} b} c", Options{KeepComments: true}, []string{"a", "b", "}", "c"}, []string{"{outer {inner}"}},
+		{"a {outer {inner} b} c", Options{NestedComments: true, KeepComments: true}, []string{"a", "c"}, []string{"{outer {inner} b}"}},
+		{"{}", Options{KeepComments: true}, []string{}, []string{"{}"}},
+		{"a {first} b {open", Options{}, []string{"a", "b"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		l := InitializeLexerWithOptions(tt.input, tt.options)
+		literals := []string{}
+
+		for tok := l.NextToken(); token.EOF != tok.Type; tok = l.NextToken() {
+			literals = append(literals, tok.Literal)
+		}
+
+		if strings.Join(literals, " ") != strings.Join(tt.expectedTokens, " ") {
+			t.Errorf("wrong tokens for %q, expected=%q, got=%q", tt.input, tt.expectedTokens, literals)
+		}
+
+		comments := l.Comments()
+
+		if len(comments) != len(tt.expectedComments) {
+			t.Fatalf("wrong number of comments for %q, expected=%d, got=%d", tt.input, len(tt.expectedComments), len(comments))
+		}
+
+		for i, comment := range comments {
+			if token.COMMENT != comment.Type || comment.Literal != tt.expectedComments[i] {
+				t.Errorf("comments[%d] wrong for %q, expected=%q, got=%s %q", i, tt.input, tt.expectedComments[i], comment.Type, comment.Literal)
+			}
+		}
+	}
+}
+
+// TestCommentPosition :
+func TestCommentPosition(t *testing.T) {
+	l := InitializeLexerWithOptions("x :=\n  {skip} y", Options{KeepComments: true})
+
+	if tok := l.NextToken(); "x" != tok.Literal {
+		t.Fatalf("expected=%q, got=%q", "x", tok.Literal)
+	}
+
+	l.NextToken()
+	tok := l.NextToken()
+
+	if "y" != tok.Literal || "2:10" != tok.Position.String() {
+		t.Fatalf("expected=%q at 2:10, got=%q at %s", "y", tok.Literal, tok.Position)
+	}
+
+	comment := l.Comments()[0]
+
+	if "2:3" != comment.Position.String() || 7 != comment.Position.Offset {
+		t.Fatalf("comment position wrong, expected=2:3 (7), got=%s (%d)", comment.Position, comment.Position.Offset)
+	}
+}
+
+// TestTokenText : only the text of the current token is kept, punctuation and comments included
+func TestTokenText(t *testing.T) {
+	inputs := []string{
+		strings.Repeat("+-*/(),;.", 1000),
+		strings.Repeat("a{comment}", 1000),
+		strings.Repeat(":=<>", 1000),
+	}
+
+	for _, input := range inputs {
+		l := InitializeLexer(input)
+
+		for tok := l.NextToken(); token.EOF != tok.Type; tok = l.NextToken() {
+			if len(l.text) > len(tok.Literal) {
+				t.Fatalf("text kept after %q grew to %d bytes", tok.Literal, len(l.text))
+			}
+		}
+
+		if 0 != len(l.Comments()) {
+			t.Errorf("comments kept without the option, got=%d", len(l.Comments()))
+		}
+	}
+
+	l := InitializeLexer("{" + strings.Repeat("long comment ", 100000) + "} a")
+
+	if tok := l.NextToken(); "a" != tok.Literal || cap(l.text) > 64 {
+		t.Errorf("comment text recorded, got=%q after %d bytes", tok.Literal, cap(l.text))
+	}
+}
+
+// TestUnterminatedComment :
+func TestUnterminatedComment(t *testing.T) {
+	tests := []struct {
+		input    string
+		options  Options
+		expected string
+	}{
+		{"a := 1;\n  {never closed", Options{KeepComments: true}, "2:3: unterminated comment"},
+		{"{", Options{KeepComments: true}, "1:1: unterminated comment"},
+		{"x {a {b} c", Options{NestedComments: true, KeepComments: true}, "1:3: unterminated comment"},
+	}
+
+	for _, tt := range tests {
+		l := InitializeLexerWithOptions(tt.input, tt.options)
+		tok := l.NextToken()
+
+		for ; token.EOF != tok.Type; tok = l.NextToken() {
+		}
+
+		diagnostics := l.Diagnostics()
+
+		if 1 != len(diagnostics) {
+			t.Fatalf("wrong number of diagnostics for %q, expected=1, got=%d", tt.input, len(diagnostics))
+		}
+
+		if diagnostics[0].String() != tt.expected {
+			t.Errorf("wrong diagnostic, expected=%q, got=%q", tt.expected, diagnostics[0].String())
+		}
+
+		if diagnostic.UNTERMINATED_COMMENT != diagnostics[0].Code {
+			t.Errorf("wrong code, got=%s", diagnostics[0].Code)
+		}
+
+		if len(l.Comments()) != 1 || tok.Position.Offset != len(tt.input) {
+			t.Errorf("comment not running until the end of %q", tt.input)
+		}
+	}
+}
+
+// TestLexicalErrors : every error becomes a diagnostic and lexing goes on after it
+func TestLexicalErrors(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedLiterals   []string
+		expectedCode       diagnostic.Code
+		expectedDiagnostic string
+	}{
+		{"x := 12abc;", []string{"x", ":=", "12abc", ";"}, diagnostic.MALFORMED_NUMBER, "1:6: malformed number \"12abc\""},
+		{"x := 12. + 1", []string{"x", ":=", "12.", "+", "1"}, diagnostic.MALFORMED_NUMBER, "1:6: malformed number \"12.\""},
+		{"1.a23 b", []string{"1.a23", "b"}, diagnostic.MALFORMED_NUMBER, "1:1: malformed number \"1.a23\""},
+		{"a\n 99999999999999999999 b", []string{"a", "99999999999999999999", "b"}, diagnostic.NUMBER_OUT_OF_RANGE, "2:2: number out of range \"99999999999999999999\", the largest 64-bit integer is 9223372036854775807"},
+		{"1e999999", []string{"1e999999"}, diagnostic.NUMBER_OUT_OF_RANGE, "1:1: number out of range \"1e999999\""},
+		{"2e + 1", []string{"2e", "+", "1"}, diagnostic.MALFORMED_NUMBER, "1:1: malformed number \"2e\""},
+		{"1.5e-x", []string{"1.5e-x"}, diagnostic.MALFORMED_NUMBER, "1:1: malformed number \"1.5e-x\""},
+		{"1.e5", []string{"1.e5"}, diagnostic.MALFORMED_NUMBER, "1:1: malformed number \"1.e5\""},
+		{"1__000;", []string{"1__000", ";"}, diagnostic.MALFORMED_NUMBER, "1:1: malformed number \"1__000\""},
+		{"1_ + 2", []string{"1_", "+", "2"}, diagnostic.MALFORMED_NUMBER, "1:1: malformed number \"1_\""},
+		{"0.5_", []string{"0.5_"}, diagnostic.MALFORMED_NUMBER, "1:1: malformed number \"0.5_\""},
+		{"a # b", []string{"a", "#", "b"}, diagnostic.INVALID_CHARACTER, "1:3: illegal character \"#\""},
+		{
+			"var abcdefghijklmnopqrstuvwxyz0123456789: integer;",
+			[]string{"var", "abcdefghijklmnopqrstuvwxyz0123456789", ":", "integer", ";"},
+			diagnostic.IDENTIFIER_TOO_LONG,
+			"1:5: identifier too long, \"abcdefghijklmnopqrstuvwxyz0123456789\" has 36 characters, the limit is 32",
+		},
+	}
+
+	for _, tt := range tests {
+		l := InitializeLexer(tt.input)
+		literals := []string{}
+
+		for tok := l.NextToken(); token.EOF != tok.Type; tok = l.NextToken() {
+			literals = append(literals, tok.Literal)
+		}
+
+		if strings.Join(literals, " ") != strings.Join(tt.expectedLiterals, " ") {
+			t.Errorf("wrong tokens for %q, expected=%q, got=%q", tt.input, tt.expectedLiterals, literals)
+		}
+
+		diagnostics := l.Diagnostics()
+
+		if 1 != len(diagnostics) {
+			t.Fatalf("wrong number of diagnostics for %q, expected=1, got=%d", tt.input, len(diagnostics))
+		}
+
+		if diagnostics[0].String() != tt.expectedDiagnostic {
+			t.Errorf("wrong diagnostic, expected=%q, got=%q", tt.expectedDiagnostic, diagnostics[0].String())
+		}
+
+		if diagnostics[0].Code != tt.expectedCode {
+			t.Errorf("wrong code for %q, expected=%s, got=%s", tt.input, tt.expectedCode, diagnostics[0].Code)
+		}
+	}
+}
+
+// TestStrings : the literal keeps the quotes as written, unterminated strings end with their line
+func TestStrings(t *testing.T) {
+	input := "write('result: ', x, '', 'it''s', '''');\nwrite('a, b)\n'é'"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{token.WRITE, "write", 1},
+		{token.LEFT_PARENTHESIS, "(", 6},
+		{token.STRING, "'result: '", 7},
+		{token.COMMA, ",", 17},
+		{token.IDENTIFIER, "x", 19},
+		{token.COMMA, ",", 20},
+		{token.STRING, "''", 22},
+		{token.COMMA, ",", 24},
+		{token.STRING, "'it''s'", 26},
+		{token.COMMA, ",", 33},
+		{token.STRING, "''''", 35},
+		{token.RIGHT_PARENTHESIS, ")", 39},
+		{token.SEMICOLON, ";", 40},
+		{token.WRITE, "write", 1},
+		{token.LEFT_PARENTHESIS, "(", 6},
+		{token.ILLEGAL, "'a, b)", 7},
+		{token.STRING, "'é'", 1},
+		{token.EOF, "", 4},
+	}
+
+	l := InitializeLexer(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral || tok.Position.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - token wrong\n\texpected=%s %q at column %d, got=%s %q at %s", i, tt.expectedType, tt.expectedLiteral, tt.expectedColumn, tok.Type, tok.Literal, tok.Position)
+		}
+	}
+
+	diagnostics := l.Diagnostics()
+
+	if 1 != len(diagnostics) || "2:7: unterminated string" != diagnostics[0].String() || diagnostic.UNTERMINATED_STRING != diagnostics[0].Code {
+		t.Fatalf("wrong diagnostics, expected=%q, got=%q", "2:7: unterminated string", diagnostics.Strings())
+	}
+
+	if "2:13" != diagnostics[0].End.String() {
+		t.Errorf("unterminated string should run until the end of the line, got=%s", diagnostics[0].End)
+	}
+}
+
+// TestNumbers :
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType token.TokenType
+	}{
+		{"0", token.INTEGER},
+		{"010", token.INTEGER},
+		{"1_000_000", token.INTEGER},
+		{"9223372036854775807", token.INTEGER},
+		{"2.5", token.REAL},
+		{"1_000.000_1", token.REAL},
+		{"1.5e-3", token.REAL},
+		{"1.5E+3", token.REAL},
+		{"2e10", token.REAL},
+		{"1e-999", token.REAL},
+	}
+
+	for _, tt := range tests {
+		l := InitializeLexer(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.input {
+			t.Errorf("wrong token, expected=%s %q, got=%s %q", tt.expectedType, tt.input, tok.Type, tok.Literal)
+		}
+
+		if 0 != len(l.Diagnostics()) {
+			t.Errorf("unexpected diagnostics for %q: %q", tt.input, l.Diagnostics().Strings())
+		}
+
+		if tok = l.NextToken(); token.EOF != tok.Type {
+			t.Errorf("number %q not read as a whole, got=%s %q after it", tt.input, tok.Type, tok.Literal)
+		}
+	}
+}
+
+// TestIntegerWidth :
+func TestIntegerWidth(t *testing.T) {
+	tests := []struct {
+		width    int
+		input    string
+		expected string
+	}{
+		{16, "32767", ""},
+		{16, "32_769", "1:1: number out of range \"32_769\", the largest 16-bit integer is 32767"},
+		{16, "32_768", ""},
+		{16, "40000.0", ""},
+		{32, "2147483647", ""},
+		{32, "2147483649", "1:1: number out of range \"2147483649\", the largest 32-bit integer is 2147483647"},
+		{64, "2147483648", ""},
+		{0, "9223372036854775809", "1:1: number out of range \"9223372036854775809\", the largest 64-bit integer is 9223372036854775807"},
+		{0, "18446744073709551616", "1:1: number out of range \"18446744073709551616\", the largest 64-bit integer is 9223372036854775807"},
+		{16, "-32768", ""},
+		{16, "-32769", "1:2: number out of range \"32769\", the largest 16-bit integer is 32767"},
+		{16, "- 32_768", ""},
+		{32, "-2147483648", ""},
+		{64, "-9223372036854775808", ""},
+		{64, "-9223372036854775809", "1:2: number out of range \"9223372036854775809\", the largest 64-bit integer is 9223372036854775807"},
+	}
+
+	for _, tt := range tests {
+		l := InitializeLexerWithOptions(tt.input, Options{IntegerWidth: tt.width})
+		tok := l.NextToken()
+
+		if token.MINUS == tok.Type {
+			tok = l.NextToken()
+		}
+
+		if "" == tt.expected {
+			if 0 != len(l.Diagnostics()) || token.ILLEGAL == tok.Type {
+				t.Errorf("%q should fit in %d bits, got=%q", tt.input, tt.width, l.Diagnostics().Strings())
+			}
+
+			continue
+		}
+
+		if token.ILLEGAL != tok.Type || 1 != len(l.Diagnostics()) || l.Diagnostics()[0].String() != tt.expected {
+			t.Errorf("wrong diagnostics for %q in %d bits, expected=%q, got=%s %q", tt.input, tt.width, tt.expected, tok.Type, l.Diagnostics().Strings())
+		}
+	}
+}
+
+// TestMaxIdentifierLength :
+func TestMaxIdentifierLength(t *testing.T) {
+	tests := []struct {
+		options  Options
+		input    string
+		expected int
+	}{
+		{Options{MaxIdentifierLength: 3}, "ab1 ab12", 1},
+		{Options{MaxIdentifierLength: 4}, "abc abcd", 0},
+		{Options{}, "abcdefghijklmnopqrstuvwxyz0123456789", 0},
+	}
+
+	for _, tt := range tests {
+		l := InitializeLexerWithOptions(tt.input, tt.options)
+
+		for tok := l.NextToken(); token.EOF != tok.Type; tok = l.NextToken() {
+			if token.IDENTIFIER != tok.Type {
+				t.Errorf("too long identifiers are still identifiers, got=%s", tok.Type)
+			}
+		}
+
+		if len(l.Diagnostics()) != tt.expected {
+			t.Errorf("wrong number of diagnostics for %q with %+v, expected=%d, got=%d", tt.input, tt.options, tt.expected, len(l.Diagnostics()))
+		}
+	}
+}
+
+// TestOperators :
+func TestOperators(t *testing.T) {
+	input := `a = b <> c < d <= e > f >= g == h
+not x and y or z div 2 mod 3`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENTIFIER, "a"},
+		{token.EQUAL, "="},
+		{token.IDENTIFIER, "b"},
+		{token.DIFFERENT, "<>"},
+		{token.IDENTIFIER, "c"},
+		{token.LESS_THAN, "<"},
+		{token.IDENTIFIER, "d"},
+		{token.LESS_THAN_EQUAL, "<="},
+		{token.IDENTIFIER, "e"},
+		{token.GREATER_THAN, ">"},
+		{token.IDENTIFIER, "f"},
+		{token.GREATER_THAN_EQUAL, ">="},
+		{token.IDENTIFIER, "g"},
+		{token.EQUAL, "=="},
+		{token.IDENTIFIER, "h"},
+		{token.NOT, "not"},
+		{token.IDENTIFIER, "x"},
+		{token.AND, "and"},
+		{token.IDENTIFIER, "y"},
+		{token.OR, "or"},
+		{token.IDENTIFIER, "z"},
+		{token.DIV, "div"},
+		{token.INTEGER, "2"},
+		{token.MOD, "mod"},
+		{token.INTEGER, "3"},
+		{token.EOF, ""},
+	}
+
+	l := InitializeLexer(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong\n\texpected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong\n\texpected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+// TestUnicode : identifiers may have any letter, columns being counted in runes and offsets in bytes
+func TestUnicode(t *testing.T) {
+	input := "soma_média := ação + 1;\n  π € ĳ\xff\uFFFDz"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedColumn  int
+		expectedOffset  int
+	}{
+		{token.IDENTIFIER, "soma_média", 1, 0},
+		{token.ASSIGN, ":=", 12, 12},
+		{token.IDENTIFIER, "ação", 15, 15},
+		{token.PLUS, "+", 20, 22},
+		{token.INTEGER, "1", 22, 24},
+		{token.SEMICOLON, ";", 23, 25},
+		{token.IDENTIFIER, "π", 3, 29},
+		{token.ILLEGAL, "€", 5, 32},
+		{token.IDENTIFIER, "ĳ", 7, 36},
+		{token.ILLEGAL, "\xff", 8, 38},
+		{token.ILLEGAL, "\uFFFD", 9, 39},
+		{token.IDENTIFIER, "z", 10, 42},
+		{token.EOF, "", 11, 43},
+	}
+
+	l := InitializeLexer(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong\n\texpected=%s %q, got=%s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+
+		if tok.Position.Column != tt.expectedColumn || tok.Position.Offset != tt.expectedOffset {
+			t.Fatalf("tests[%d] - position wrong\n\texpected=%d (%d), got=%d (%d)", i, tt.expectedColumn, tt.expectedOffset, tok.Position.Column, tok.Position.Offset)
+		}
+	}
+
+	expected := []string{
+		"2:5: illegal character \"€\"",
+		"2:8: invalid UTF-8 byte \"\\xff\"",
+		"2:9: illegal character \"\uFFFD\"",
+	}
+
+	if strings.Join(l.Diagnostics().Strings(), "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("wrong diagnostics, expected=%q, got=%q", expected, l.Diagnostics().Strings())
+	}
+
+	if diagnostic.INVALID_UTF8 != l.Diagnostics()[1].Code || 9 != l.Diagnostics()[1].End.Column {
+		t.Errorf("wrong diagnostic for the invalid byte, got=%+v", l.Diagnostics()[1])
+	}
+}
+
+// TestReaderLexer : the streaming lexer gives the same tokens, positions, comments and diagnostics as the string one
+func TestReaderLexer(t *testing.T) {
+	program := `program big;
+{ counts up to ten,
+  twice }
+var i: integer := 0;
+begin
+	while i < 10 do i := i + 1.5 * 2e;
+	writeln(i) # 99999999999999999999;
+	ação := média \xff
+end.
+`
+	input := strings.Repeat(program, 2000) + "{ never closed"
+
+	tests := []io.Reader{
+		strings.NewReader(input),
+		iotest.OneByteReader(strings.NewReader(input)),
+		iotest.HalfReader(strings.NewReader(input)),
+	}
+
+	for i, in := range tests {
+		options := DefaultOptions()
+		options.KeepComments = true
+
+		expected := InitializeLexerWithOptions(input, options)
+		l := InitializeLexerFromReaderWithOptions(in, options)
+
+		for {
+			expectedToken := expected.NextToken()
+			tok := l.NextToken()
+
+			if tok != expectedToken {
+				t.Fatalf("tests[%d] - token wrong\n\texpected=%+v, got=%+v", i, expectedToken, tok)
+			}
+
+			if token.EOF == tok.Type {
+				break
+			}
+		}
+
+		if len(l.Comments()) != len(expected.Comments()) || l.Comments()[0] != expected.Comments()[0] {
+			t.Errorf("tests[%d] - comments wrong, expected=%d, got=%d", i, len(expected.Comments()), len(l.Comments()))
+		}
+
+		if strings.Join(l.Diagnostics().Strings(), "\n") != strings.Join(expected.Diagnostics().Strings(), "\n") {
+			t.Errorf("tests[%d] - diagnostics wrong", i)
+		}
+
+		if nil != l.Err() {
+			t.Errorf("tests[%d] - unexpected error: %s", i, l.Err())
+		}
+	}
+}
+
+// TestReaderError : the input ends at the first read error, which is kept apart from the diagnostics
+func TestReaderError(t *testing.T) {
+	failure := errors.New("disk on fire")
+	l := InitializeLexerFromReader(io.MultiReader(strings.NewReader("x := 10"), iotest.ErrReader(failure)))
+	literals := []string{}
+
+	for tok := l.NextToken(); token.EOF != tok.Type; tok = l.NextToken() {
+		literals = append(literals, tok.Literal)
+	}
+
+	if "x := 10" != strings.Join(literals, " ") {
+		t.Errorf("wrong tokens, expected=%q, got=%q", "x := 10", literals)
+	}
+
+	if failure != l.Err() {
+		t.Errorf("wrong error, expected=%v, got=%v", failure, l.Err())
+	}
+
+	if 0 != len(l.Diagnostics()) {
+		t.Errorf("read errors are not diagnostics, got=%q", l.Diagnostics().Strings())
+	}
+}
+
+// benchmarkProgram : a few megabytes of LALG, mostly identifiers and keywords
+var benchmarkProgram = strings.Repeat(`procedure accumulate(step: integer, limit: real);
+	var total: real := 0;
+	begin
+		{ keywords and identifiers are looked up on every word }
+		while total <= limit do
+			begin
+				if (total > 10) and not (step = 0) then total := total + step * 2 end
+				else total := total + 1.5 end;
+				writeln(total div 2, total mod 3)
+			end
+	end;
+`, 20000)
+
+// BenchmarkLexer : run with -benchmem, the MB/s column being the throughput
+func BenchmarkLexer(b *testing.B) {
+	b.SetBytes(int64(len(benchmarkProgram)))
+
+	for i := 0; i < b.N; i++ {
+		l := InitializeLexer(benchmarkProgram)
+
+		for tok := l.NextToken(); token.EOF != tok.Type; tok = l.NextToken() {
+		}
+	}
+}
+
+// BenchmarkLookupIdentifier :
+func BenchmarkLookupIdentifier(b *testing.B) {
+	words := strings.Fields("procedure accumulate step integer limit real var total begin while do if and not then else writeln div mod end x")
+
+	for i := 0; i < b.N; i++ {
+		for _, word := range words {
+			token.LookupIdentifier(word)
+		}
+	}
+}
@@ -1,23 +1,11 @@
 package main
 
 import (
-	"fmt"
 	"os"
-	"os/user"
 
-	"./repl"
+	"./cli"
 )
 
 func main() {
-	user, err := user.Current()
-
-	if nil != err {
-		panic(err)
-	}
-
-	fmt.Printf("Hello %s! This is LALG programming language!\n", user.Username)
-	fmt.Printf("Fell free to type in commands\n")
-	fmt.Printf("To exit, just type Ctrl + C\n")
-
-	repl.Start(os.Stdin, os.Stdout)
+	os.Exit(cli.Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }
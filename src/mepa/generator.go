@@ -0,0 +1,531 @@
+package mepa
+
+import (
+	"fmt"
+	"strconv"
+
+	"../ast"
+	"../object"
+	"../semantic"
+	"../token"
+)
+
+var infixMnemonics = map[token.TokenType]string{
+	token.PLUS:               SOMA,
+	token.MINUS:              SUBT,
+	token.ASTERISK:           MULT,
+	token.SLASH:              DIVR,
+	token.DIV:                DIVI,
+	token.MOD:                MODI,
+	token.AND:                CONJ,
+	token.OR:                 DISJ,
+	token.EQUAL:              CMIG,
+	token.DIFFERENT:          CMDG,
+	token.LESS_THAN:          CMME,
+	token.LESS_THAN_EQUAL:    CMEG,
+	token.GREATER_THAN:       CMMA,
+	token.GREATER_THAN_EQUAL: CMAG,
+}
+
+var prefixMnemonics = map[token.TokenType]string{
+	token.MINUS: INVR,
+	token.NOT:   NEGA,
+}
+
+// variable : memory cell of a variable, constant or parameter, addressed relative to D[level]
+type variable struct {
+	level   int
+	address int
+	t       semantic.Type
+}
+
+// procedure : entry label and parameter types of a procedure
+type procedure struct {
+	label      string
+	parameters []semantic.Type
+}
+
+// frame : names and cells of the main program, level 0, or of the procedure being generated, level 1
+type frame struct {
+	level      int
+	variables  map[string]*variable
+	procedures map[string]*procedure
+	// Cells allocated so far and the AMEM instruction reserving them
+	allocated  int
+	allocation int
+	outer      *frame
+}
+
+// Generator : translates a checked program into MEPA code
+type Generator struct {
+	analyzer *semantic.Analyzer
+	code     Code
+	frame    *frame
+	labels   int
+	// Label to attach to the next instruction emitted
+	pending string
+}
+
+// emit :
+func (g *Generator) emit(mnemonic string, operands ...interface{}) int {
+	instruction := Instruction{
+		Label:    g.pending,
+		Mnemonic: mnemonic,
+	}
+
+	for _, operand := range operands {
+		instruction.Operands = append(instruction.Operands, fmt.Sprint(operand))
+	}
+
+	g.pending = ""
+	g.code = append(g.code, instruction)
+
+	return len(g.code) - 1
+}
+
+// newLabel :
+func (g *Generator) newLabel() string {
+	g.labels++
+
+	return "L" + strconv.Itoa(g.labels)
+}
+
+// place : attaches the label to a NADA, so jumps never depend on what comes next
+func (g *Generator) place(label string) {
+	g.pending = label
+	g.emit(NADA)
+}
+
+// openFrame : the cells are reserved by an AMEM whose size is only known when the frame is closed
+func (g *Generator) openFrame(level int) {
+	g.frame = &frame{
+		level:      level,
+		variables:  make(map[string]*variable),
+		procedures: make(map[string]*procedure),
+		allocation: g.emit(AMEM, 0),
+		outer:      g.frame,
+	}
+}
+
+// closeFrame : frees the cells of the frame, dropping the reservation when nothing was allocated
+func (g *Generator) closeFrame() {
+	if 0 == g.frame.allocated {
+		g.code = append(g.code[:g.frame.allocation], g.code[g.frame.allocation+1:]...)
+	} else {
+		g.code[g.frame.allocation].Operands = []string{strconv.Itoa(g.frame.allocated)}
+		g.emit(DMEM, g.frame.allocated)
+	}
+
+	g.frame = g.frame.outer
+}
+
+// reserve : a fresh cell in the current frame
+func (g *Generator) reserve(t semantic.Type) *variable {
+	cell := &variable{
+		level:   g.frame.level,
+		address: g.frame.allocated,
+		t:       t,
+	}
+
+	g.frame.allocated++
+
+	return cell
+}
+
+// allocate : a redeclaration gets its own cell, so it never touches the previous one
+func (g *Generator) allocate(name string, t semantic.Type) *variable {
+	cell := g.reserve(t)
+	g.frame.variables[name] = cell
+
+	return cell
+}
+
+// lookup :
+func (g *Generator) lookup(name string) (*variable, *procedure) {
+	for f := g.frame; nil != f; f = f.outer {
+		if cell, ok := f.variables[name]; ok {
+			return cell, nil
+		}
+
+		if called, ok := f.procedures[name]; ok {
+			return nil, called
+		}
+	}
+
+	return nil, nil
+}
+
+// load :
+func (g *Generator) load(cell *variable) {
+	g.emit(CRVL, cell.level, cell.address)
+}
+
+// store :
+func (g *Generator) store(cell *variable) {
+	g.emit(ARMZ, cell.level, cell.address)
+}
+
+// convert : integers are widened when stored where a real is expected
+func (g *Generator) convert(from semantic.Type, to semantic.Type) {
+	if semantic.INTEGER_TYPE == from && semantic.REAL_TYPE == to {
+		g.emit(CNVR)
+	}
+}
+
+// Generate : checks the program and translates it, failing on the first semantic error
+func (g *Generator) Generate(node ast.Node) (Code, error) {
+	g.analyzer.Analyze(node)
+
+	if errors := g.analyzer.Diagnostics().Errors(); 0 != len(errors) {
+		return nil, fmt.Errorf("%s", errors[0])
+	}
+
+	g.emit(INPP)
+	g.openFrame(0)
+
+	if err := g.generate(node); nil != err {
+		return nil, err
+	}
+
+	g.closeFrame()
+	g.emit(PARA)
+
+	return g.code, nil
+}
+
+// generate :
+func (g *Generator) generate(node ast.Node) error {
+	switch node := node.(type) {
+	case *ast.Program:
+		return g.generateStatements(node.Statements)
+	case *ast.ExpressionStatement:
+		if err := g.generate(node.Expression); nil != err {
+			return err
+		}
+
+		// The value of a loose expression is discarded
+		if t := g.analyzer.TypeOf(node.Expression); semantic.VOID_TYPE != t && semantic.INVALID_TYPE != t {
+			g.emit(DMEM, 1)
+		}
+	case *ast.BlockStatement:
+		return g.generateStatements(node.Statements)
+	case *ast.VarStatement:
+		return g.generateDeclaration(node.Name, node.Type, node.Value)
+	case *ast.ConstStatement:
+		return g.generateDeclaration(node.Name, node.Type, node.Value)
+	case *ast.AssignStatement:
+		if err := g.generate(node.Value); nil != err {
+			return err
+		}
+
+		cell, _ := g.lookup(node.Name.Value)
+		g.convert(g.analyzer.TypeOf(node.Value), cell.t)
+		g.store(cell)
+	case *ast.ReadStatement:
+		for _, argument := range node.Arguments {
+			cell, _ := g.lookup(argument.Value)
+
+			// the input is parsed with the type of the variable, as in the other backends
+			if semantic.REAL_TYPE == cell.t {
+				g.emit(LEIR)
+			} else {
+				g.emit(LEIT)
+			}
+
+			g.store(cell)
+		}
+
+		if token.READLN == node.Token.Type {
+			g.emit(LELN)
+		}
+	case *ast.WriteStatement:
+		for _, argument := range node.Arguments {
+			if err := g.generate(argument); nil != err {
+				return err
+			}
+
+			g.emit(IMPR)
+		}
+
+		if token.WRITELN == node.Token.Type {
+			g.emit(IMPL)
+		}
+	case *ast.Identifier:
+		cell, _ := g.lookup(node.Value)
+
+		if nil == cell {
+			return fmt.Errorf("%s: %s has no value", node.Token.Position, node.Value)
+		}
+
+		g.load(cell)
+	case *ast.IntegerLiteral:
+		g.emit(CRCT, node.Value)
+	case *ast.RealLiteral:
+		g.emit(CRCT, (&object.Real{Value: node.Value}).Inspect())
+	case *ast.StringLiteral:
+		// MEPA memory cells only hold numbers
+		return fmt.Errorf("%s: strings are not supported by MEPA", node.Token.Position)
+	case *ast.PrefixExpression:
+		if err := g.generate(node.Right); nil != err {
+			return err
+		}
+
+		g.emit(prefixMnemonics[node.Token.Type])
+	case *ast.InfixExpression:
+		if err := g.generate(node.Left); nil != err {
+			return err
+		}
+
+		if err := g.generate(node.Right); nil != err {
+			return err
+		}
+
+		g.emit(infixMnemonics[node.Token.Type])
+	case *ast.ConditionalExpression:
+		return g.generateConditionalExpression(node)
+	case *ast.WhileLiteral:
+		return g.generateWhileLiteral(node)
+	case *ast.ForLiteral:
+		return g.generateForLiteral(node)
+	case *ast.ProcedureLiteral:
+		return g.generateProcedures([]*ast.ProcedureLiteral{node})
+	case *ast.CallExpression:
+		return g.generateCallExpression(node)
+	case *ast.ProgramLiteral:
+		if err := g.generateStatements(node.Declarations); nil != err {
+			return err
+		}
+
+		if 0 != len(node.Procedures) {
+			if err := g.generateProcedures(node.Procedures); nil != err {
+				return err
+			}
+		}
+
+		return g.generate(node.Body)
+	}
+
+	return nil
+}
+
+// generateStatements :
+func (g *Generator) generateStatements(statements []ast.Statement) error {
+	for _, statement := range statements {
+		if err := g.generate(statement); nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// generateDeclaration : the cell starts with the value, or with the zero of its type
+func (g *Generator) generateDeclaration(name *ast.Identifier, keyword token.Token, value ast.Expression) error {
+	t := semantic.TypeOfKeyword(keyword)
+
+	if nil == value {
+		g.emit(CRCT, object.Zero(keyword.Type).Inspect())
+	} else {
+		if err := g.generate(value); nil != err {
+			return err
+		}
+
+		g.convert(g.analyzer.TypeOf(value), t)
+	}
+
+	g.store(g.allocate(name.Value, t))
+
+	return nil
+}
+
+// generateConditionalExpression :
+func (g *Generator) generateConditionalExpression(node *ast.ConditionalExpression) error {
+	alternative := g.newLabel()
+
+	if err := g.generate(node.Condition); nil != err {
+		return err
+	}
+
+	g.emit(DSVF, alternative)
+
+	if err := g.generate(node.Consequence); nil != err {
+		return err
+	}
+
+	if nil == node.Alternative {
+		g.place(alternative)
+
+		return nil
+	}
+
+	end := g.newLabel()
+
+	g.emit(DSVS, end)
+	g.place(alternative)
+
+	if err := g.generate(node.Alternative); nil != err {
+		return err
+	}
+
+	g.place(end)
+
+	return nil
+}
+
+// generateWhileLiteral :
+func (g *Generator) generateWhileLiteral(node *ast.WhileLiteral) error {
+	start := g.newLabel()
+	end := g.newLabel()
+
+	g.place(start)
+
+	if err := g.generate(node.Condition); nil != err {
+		return err
+	}
+
+	g.emit(DSVF, end)
+
+	if err := g.generate(node.Body); nil != err {
+		return err
+	}
+
+	g.emit(DSVS, start)
+	g.place(end)
+
+	return nil
+}
+
+// generateForLiteral : the bounds are kept in hidden cells, evaluated once, and the loop variable is only assigned
+// when an iteration runs, as in the evaluator
+func (g *Generator) generateForLiteral(node *ast.ForLiteral) error {
+	cell, _ := g.lookup(node.Variable.Value)
+	counter := g.reserve(semantic.INTEGER_TYPE)
+	limit := g.reserve(semantic.INTEGER_TYPE)
+
+	if err := g.generate(node.From); nil != err {
+		return err
+	}
+
+	g.store(counter)
+
+	if err := g.generate(node.To); nil != err {
+		return err
+	}
+
+	g.store(limit)
+
+	start := g.newLabel()
+	end := g.newLabel()
+
+	g.place(start)
+	g.load(counter)
+	g.load(limit)
+	g.emit(CMEG)
+	g.emit(DSVF, end)
+	g.load(counter)
+	g.store(cell)
+
+	if err := g.generate(node.Body); nil != err {
+		return err
+	}
+
+	// the last value ends the loop before the counter goes past it, which would overflow at the largest integer
+	g.load(counter)
+	g.load(limit)
+	g.emit(CMDG)
+	g.emit(DSVF, end)
+	g.load(counter)
+	g.emit(CRCT, 1)
+	g.emit(SOMA)
+	g.store(counter)
+	g.emit(DSVS, start)
+	g.place(end)
+
+	return nil
+}
+
+// generateProcedures : the procedures are jumped over, they only run when called, and each one is known before its
+// body is generated so it may call itself
+func (g *Generator) generateProcedures(procedures []*ast.ProcedureLiteral) error {
+	if 0 != g.frame.level {
+		return fmt.Errorf("%s: nested procedures are not supported", procedures[0].Token.Position)
+	}
+
+	after := g.newLabel()
+
+	g.emit(DSVS, after)
+
+	for _, literal := range procedures {
+		called := &procedure{
+			label: g.newLabel(),
+		}
+
+		g.frame.procedures[literal.Name] = called
+		delete(g.frame.variables, literal.Name)
+
+		g.pending = called.label
+		g.emit(ENPR, 1)
+		g.openFrame(1)
+
+		// Parameters sit below the return address and the saved display, at D[1] - (n + 2) + j
+		for j, parameter := range literal.Parameters {
+			t := semantic.TypeOfKeyword(parameter.Type)
+
+			called.parameters = append(called.parameters, t)
+			g.frame.variables[parameter.Value] = &variable{
+				level:   1,
+				address: j - len(literal.Parameters) - 2,
+				t:       t,
+			}
+		}
+
+		if err := g.generateStatements(literal.Declarations); nil != err {
+			return err
+		}
+
+		if err := g.generate(literal.Body); nil != err {
+			return err
+		}
+
+		g.closeFrame()
+		g.emit(RTPR, 1, len(literal.Parameters))
+	}
+
+	g.place(after)
+
+	return nil
+}
+
+// generateCallExpression : arguments are passed by value, widened to their parameter types
+func (g *Generator) generateCallExpression(node *ast.CallExpression) error {
+	identifier, ok := node.Procedure.(*ast.Identifier)
+
+	if !ok {
+		return fmt.Errorf("%s: only procedures can be called", node.Token.Position)
+	}
+
+	_, called := g.lookup(identifier.Value)
+
+	if nil == called {
+		return fmt.Errorf("%s: %s is not a procedure", node.Token.Position, identifier.Value)
+	}
+
+	for i, argument := range node.Arguments {
+		if err := g.generate(argument); nil != err {
+			return err
+		}
+
+		g.convert(g.analyzer.TypeOf(argument), called.parameters[i])
+	}
+
+	g.emit(CHPR, called.label)
+
+	return nil
+}
+
+// InitializeGenerator :
+func InitializeGenerator() *Generator {
+	return &Generator{
+		analyzer: semantic.InitializeAnalyzer(),
+	}
+}
@@ -0,0 +1,142 @@
+package mepa
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Mnemonics of the MEPA machine, as defined by Kowaltowski. Besides the standard set there are a few extensions, needed
+// because LALG has reals and line oriented input and output:
+//
+//	CRCT k    also takes real constants, as in "CRCT 2.5"
+//	DIVR      real division, the "/" operator, DIVI being "div"
+//	MODI      integer remainder, the "mod" operator
+//	CNVR      converts the integer on the top of the stack to a real
+//	LEIR      reads a real, LEIT only accepting integers
+//	LELN      discards what is left of the input line, used by readln
+//	IMPL      writes a line break, used by writeln
+const (
+	INPP = "INPP"
+	PARA = "PARA"
+	AMEM = "AMEM"
+	DMEM = "DMEM"
+	CRCT = "CRCT"
+	CRVL = "CRVL"
+	ARMZ = "ARMZ"
+	SOMA = "SOMA"
+	SUBT = "SUBT"
+	MULT = "MULT"
+	DIVI = "DIVI"
+	INVR = "INVR"
+	CONJ = "CONJ"
+	DISJ = "DISJ"
+	NEGA = "NEGA"
+	CMME = "CMME"
+	CMMA = "CMMA"
+	CMIG = "CMIG"
+	CMDG = "CMDG"
+	CMEG = "CMEG"
+	CMAG = "CMAG"
+	DSVS = "DSVS"
+	DSVF = "DSVF"
+	NADA = "NADA"
+	LEIT = "LEIT"
+	IMPR = "IMPR"
+	CHPR = "CHPR"
+	ENPR = "ENPR"
+	RTPR = "RTPR"
+
+	DIVR = "DIVR"
+	MODI = "MODI"
+	CNVR = "CNVR"
+	LEIR = "LEIR"
+	LELN = "LELN"
+	IMPL = "IMPL"
+)
+
+// operandCounts : number of operands each mnemonic takes
+var operandCounts = map[string]int{
+	INPP: 0, PARA: 0, AMEM: 1, DMEM: 1, CRCT: 1, CRVL: 2, ARMZ: 2,
+	SOMA: 0, SUBT: 0, MULT: 0, DIVI: 0, INVR: 0, CONJ: 0, DISJ: 0, NEGA: 0,
+	CMME: 0, CMMA: 0, CMIG: 0, CMDG: 0, CMEG: 0, CMAG: 0,
+	DSVS: 1, DSVF: 1, NADA: 0, LEIT: 0, IMPR: 0, CHPR: 1, ENPR: 1, RTPR: 2,
+	DIVR: 0, MODI: 0, CNVR: 0, LEIR: 0, LELN: 0, IMPL: 0,
+}
+
+// Instruction : a single line of MEPA code, optionally labeled
+type Instruction struct {
+	Label    string
+	Mnemonic string
+	Operands []string
+}
+
+// Code : a whole MEPA program
+type Code []Instruction
+
+// String : "L1   DSVF L2", the label column being five characters wide
+func (i Instruction) String() string {
+	instruction := i.Mnemonic
+
+	if 0 != len(i.Operands) {
+		instruction += " " + strings.Join(i.Operands, ",")
+	}
+
+	return fmt.Sprintf("%-5s%s", i.Label, instruction)
+}
+
+// String : one instruction per line
+func (c Code) String() string {
+	var out bytes.Buffer
+
+	for _, instruction := range c {
+		out.WriteString(instruction.String())
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+// Parse : reads MEPA text, a line whose first word is not a mnemonic, or ends in a colon, begins with its label
+func Parse(text string) (Code, error) {
+	code := Code{}
+
+	for number, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+
+		if 0 == len(fields) {
+			continue
+		}
+
+		instruction := Instruction{}
+
+		if _, mnemonic := operandCounts[strings.ToUpper(fields[0])]; !mnemonic || strings.HasSuffix(fields[0], ":") {
+			instruction.Label = strings.TrimSuffix(fields[0], ":")
+			fields = fields[1:]
+
+			if 0 == len(fields) {
+				return nil, fmt.Errorf("line %d: label %s without an instruction", number+1, instruction.Label)
+			}
+		}
+
+		instruction.Mnemonic = strings.ToUpper(fields[0])
+
+		count, ok := operandCounts[instruction.Mnemonic]
+
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown instruction %s", number+1, fields[0])
+		}
+
+		if 1 < len(fields) {
+			instruction.Operands = strings.Split(strings.Join(fields[1:], ""), ",")
+		}
+
+		if count != len(instruction.Operands) {
+			return nil, fmt.Errorf("line %d: %s takes %d operands, got %d", number+1, instruction.Mnemonic, count, len(instruction.Operands))
+		}
+
+		code = append(code, instruction)
+	}
+
+	return code, nil
+}
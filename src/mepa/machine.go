@@ -0,0 +1,438 @@
+package mepa
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"../object"
+	"../token"
+)
+
+const (
+	// MAX_LEVELS : size of the display, LALG only nests procedures inside the main program
+	MAX_LEVELS = 2
+	// MEMORY_SIZE : cells of M, the stack growing past them being an error rather than taking all the memory there is
+	MEMORY_SIZE = 1 << 20
+)
+
+// operators : LALG operator applied by each arithmetic and comparison instruction
+var operators = map[string]token.TokenType{
+	SOMA: token.PLUS,
+	SUBT: token.MINUS,
+	MULT: token.ASTERISK,
+	DIVI: token.DIV,
+	DIVR: token.SLASH,
+	MODI: token.MOD,
+	CMIG: token.EQUAL,
+	CMDG: token.DIFFERENT,
+	CMME: token.LESS_THAN,
+	CMEG: token.LESS_THAN_EQUAL,
+	CMMA: token.GREATER_THAN,
+	CMAG: token.GREATER_THAN_EQUAL,
+}
+
+// popCounts : values each instruction takes from the stack, the ones not listed take none
+var popCounts = map[string]int{
+	ARMZ: 1, INVR: 1, NEGA: 1, CNVR: 1, DSVF: 1, IMPR: 1,
+	SOMA: 2, SUBT: 2, MULT: 2, DIVI: 2, DIVR: 2, MODI: 2, CONJ: 2, DISJ: 2,
+	CMIG: 2, CMDG: 2, CMME: 2, CMEG: 2, CMMA: 2, CMAG: 2, RTPR: 2,
+}
+
+// Machine : MEPA interpreter, M is the memory, D the display, s the top of the stack and i the next instruction.
+// Booleans are stored as the integers 1 and 0
+type Machine struct {
+	code       Code
+	labels     map[string]int
+	M          []object.Object
+	D          [MAX_LEVELS]int
+	s          int
+	i          int
+	in         *object.Input
+	out        io.Writer
+	arithmetic object.Arithmetic
+}
+
+// top :
+func (m *Machine) top() object.Object {
+	return m.M[m.s]
+}
+
+// push :
+func (m *Machine) push(value object.Object) error {
+	if MEMORY_SIZE <= m.s+1 {
+		return fmt.Errorf("stack overflow")
+	}
+
+	m.s++
+
+	for len(m.M) <= m.s {
+		m.M = append(m.M, nil)
+	}
+
+	m.M[m.s] = value
+
+	return nil
+}
+
+// pop :
+func (m *Machine) pop() object.Object {
+	value := m.M[m.s]
+	m.s--
+
+	return value
+}
+
+// integer : value of an integer cell, booleans included
+func integer(value object.Object) (int64, error) {
+	if number, ok := value.(*object.Integer); ok {
+		return number.Value, nil
+	}
+
+	return 0, fmt.Errorf("expected an integer, got %s", value.Inspect())
+}
+
+// truth : booleans as MEPA stores them
+func truth(value bool) object.Object {
+	if value {
+		return &object.Integer{Value: 1}
+	}
+
+	return &object.Integer{Value: 0}
+}
+
+// address : cell given by the "k,n" operands, relative to D[k]
+func (m *Machine) address(operands []string) (int, error) {
+	level, err := strconv.Atoi(operands[0])
+
+	if nil != err || 0 > level || MAX_LEVELS <= level {
+		return 0, fmt.Errorf("invalid level %s", operands[0])
+	}
+
+	offset, err := strconv.Atoi(operands[1])
+
+	if nil != err {
+		return 0, fmt.Errorf("invalid offset %s", operands[1])
+	}
+
+	address := m.D[level] + offset
+
+	if 0 > address || address >= len(m.M) {
+		return 0, fmt.Errorf("address %d out of the memory", address)
+	}
+
+	return address, nil
+}
+
+// constant : operand of CRCT, a real when it has a decimal point or an exponent
+func constant(operand string) (object.Object, error) {
+	if strings.ContainsAny(operand, ".eE") {
+		value, err := strconv.ParseFloat(operand, 64)
+
+		if nil != err {
+			return nil, fmt.Errorf("invalid constant %s", operand)
+		}
+
+		return &object.Real{Value: value}, nil
+	}
+
+	value, err := strconv.ParseInt(operand, 10, 64)
+
+	if nil != err {
+		return nil, fmt.Errorf("invalid constant %s", operand)
+	}
+
+	return &object.Integer{Value: value}, nil
+}
+
+// number : operand of AMEM, DMEM, ENPR and RTPR
+func number(operand string) (int, error) {
+	value, err := strconv.Atoi(operand)
+
+	if nil != err {
+		return 0, fmt.Errorf("invalid operand %s", operand)
+	}
+
+	return value, nil
+}
+
+// jump :
+func (m *Machine) jump(label string) error {
+	target, ok := m.labels[label]
+
+	if !ok {
+		return fmt.Errorf("undefined label %s", label)
+	}
+
+	m.i = target
+
+	return nil
+}
+
+// Run : executes from the first instruction until PARA
+func (m *Machine) Run() error {
+	for m.i < len(m.code) {
+		instruction := m.code[m.i]
+
+		halt, err := m.execute(instruction)
+
+		if nil != err {
+			return fmt.Errorf("instruction %d (%s): %s", m.i, strings.TrimSpace(instruction.String()), err)
+		}
+
+		if halt {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("program ended without PARA")
+}
+
+// execute : runs a single instruction, telling whether the machine halted
+func (m *Machine) execute(instruction Instruction) (bool, error) {
+	operands := instruction.Operands
+	next := m.i + 1
+
+	if m.s+1 < popCounts[instruction.Mnemonic] {
+		return false, fmt.Errorf("stack underflow")
+	}
+
+	switch instruction.Mnemonic {
+	case INPP:
+		m.s = -1
+		m.D[0] = 0
+	case PARA:
+		return true, nil
+	case AMEM:
+		n, err := number(operands[0])
+
+		if nil != err {
+			return false, err
+		}
+
+		for j := 0; j < n; j++ {
+			if err := m.push(&object.Integer{Value: 0}); nil != err {
+				return false, err
+			}
+		}
+	case DMEM:
+		n, err := number(operands[0])
+
+		if nil != err {
+			return false, err
+		}
+
+		m.s -= n
+	case CRCT:
+		value, err := constant(operands[0])
+
+		if nil != err {
+			return false, err
+		}
+
+		if err := m.push(value); nil != err {
+			return false, err
+		}
+	case CRVL:
+		address, err := m.address(operands)
+
+		if nil != err {
+			return false, err
+		}
+
+		if err := m.push(m.M[address]); nil != err {
+			return false, err
+		}
+	case ARMZ:
+		address, err := m.address(operands)
+
+		if nil != err {
+			return false, err
+		}
+
+		m.M[address] = m.pop()
+	case SOMA, SUBT, MULT, DIVI, DIVR, MODI, CMIG, CMDG, CMME, CMEG, CMMA, CMAG:
+		right := m.pop()
+		left := m.pop()
+
+		result, err := m.arithmetic.Binary(operators[instruction.Mnemonic], left, right)
+
+		if nil != err {
+			return false, err
+		}
+
+		if boolean, ok := result.(*object.Boolean); ok {
+			result = truth(boolean.Value)
+		}
+
+		if err := m.push(result); nil != err {
+			return false, err
+		}
+	case CONJ, DISJ:
+		right, err := integer(m.pop())
+
+		if nil != err {
+			return false, err
+		}
+
+		left, err := integer(m.pop())
+
+		if nil != err {
+			return false, err
+		}
+
+		result := truth(1 == left || 1 == right)
+
+		if CONJ == instruction.Mnemonic {
+			result = truth(1 == left && 1 == right)
+		}
+
+		if err := m.push(result); nil != err {
+			return false, err
+		}
+	case NEGA:
+		value, err := integer(m.pop())
+
+		if nil != err {
+			return false, err
+		}
+
+		if err := m.push(truth(1 != value)); nil != err {
+			return false, err
+		}
+	case INVR:
+		result, err := m.arithmetic.Unary(token.MINUS, m.pop())
+
+		if nil != err {
+			return false, err
+		}
+
+		if err := m.push(result); nil != err {
+			return false, err
+		}
+	case CNVR:
+		if value, ok := m.top().(*object.Integer); ok {
+			m.M[m.s] = &object.Real{Value: float64(value.Value)}
+		}
+	case DSVS:
+		return false, m.jump(operands[0])
+	case DSVF:
+		value, err := integer(m.pop())
+
+		if nil != err {
+			return false, err
+		}
+
+		if 0 == value {
+			return false, m.jump(operands[0])
+		}
+	case NADA:
+	case LEIT, LEIR:
+		template := object.Object(&object.Integer{})
+
+		if LEIR == instruction.Mnemonic {
+			template = &object.Real{}
+		}
+
+		value, err := m.in.Read(template)
+
+		if nil != err {
+			return false, fmt.Errorf("could not read: %s", err)
+		}
+
+		if err := m.push(value); nil != err {
+			return false, err
+		}
+	case LELN:
+		m.in.SkipLine()
+	case IMPR:
+		io.WriteString(m.out, m.pop().Inspect())
+	case IMPL:
+		io.WriteString(m.out, "\n")
+	case CHPR:
+		if err := m.push(&object.Integer{Value: int64(next)}); nil != err {
+			return false, err
+		}
+
+		return false, m.jump(operands[0])
+	case ENPR:
+		k, err := number(operands[0])
+
+		if nil != err || 0 > k || MAX_LEVELS <= k {
+			return false, fmt.Errorf("invalid level %s", operands[0])
+		}
+
+		if err := m.push(&object.Integer{Value: int64(m.D[k])}); nil != err {
+			return false, err
+		}
+
+		m.D[k] = m.s + 1
+	case RTPR:
+		k, err := number(operands[0])
+
+		if nil != err || 0 > k || MAX_LEVELS <= k {
+			return false, fmt.Errorf("invalid level %s", operands[0])
+		}
+
+		n, err := number(operands[1])
+
+		if nil != err {
+			return false, err
+		}
+
+		display, err := integer(m.M[m.s])
+
+		if nil != err {
+			return false, err
+		}
+
+		back, err := integer(m.M[m.s-1])
+
+		if nil != err {
+			return false, err
+		}
+
+		m.D[k] = int(display)
+		m.s -= n + 2
+		next = int(back)
+	default:
+		return false, fmt.Errorf("unknown instruction")
+	}
+
+	m.i = next
+
+	return false, nil
+}
+
+// InitializeMachine : resolves the labels, which must be unique, before anything runs
+func InitializeMachine(code Code, in io.Reader, out io.Writer) (*Machine, error) {
+	return InitializeMachineWithIntegerWidth(code, in, out, object.INTEGER_WIDTH)
+}
+
+// InitializeMachineWithIntegerWidth : integer results that do not fit in width bits stop the machine
+func InitializeMachineWithIntegerWidth(code Code, in io.Reader, out io.Writer, width int) (*Machine, error) {
+	labels := make(map[string]int)
+
+	for i, instruction := range code {
+		if "" == instruction.Label {
+			continue
+		}
+
+		if _, ok := labels[instruction.Label]; ok {
+			return nil, fmt.Errorf("label %s defined twice", instruction.Label)
+		}
+
+		labels[instruction.Label] = i
+	}
+
+	return &Machine{
+		code:       code,
+		labels:     labels,
+		M:          []object.Object{},
+		s:          -1,
+		in:         object.InitializeInput(in, width),
+		out:        out,
+		arithmetic: object.Arithmetic{Width: width},
+	}, nil
+}
@@ -0,0 +1,432 @@
+package mepa
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"../evaluator"
+	"../lexer"
+	"../object"
+	"../parser"
+)
+
+// testGenerate :
+func testGenerate(t *testing.T, input string) (Code, error) {
+	l := lexer.InitializeLexer(input)
+	p := parser.InitializeParser(l)
+	program := p.ParseProgram()
+
+	if 0 != len(p.Errors()) {
+		t.Fatalf("parser errors for %q: %q", input, p.Errors())
+	}
+
+	return InitializeGenerator().Generate(program)
+}
+
+// testExecute : runs the generated code and what is parsed back from its text, which must behave the same
+func testExecute(t *testing.T, input string, stdin string) (string, error) {
+	code, err := testGenerate(t, input)
+
+	if nil != err {
+		t.Fatalf("generator error for %q: %s", input, err)
+	}
+
+	parsed, err := Parse(code.String())
+
+	if nil != err {
+		t.Fatalf("could not parse the generated code: %s\n%s", err, code)
+	}
+
+	outputs := []string{}
+
+	for _, loaded := range []Code{code, parsed} {
+		var out bytes.Buffer
+
+		machine, err := InitializeMachine(loaded, strings.NewReader(stdin), &out)
+
+		if nil != err {
+			t.Fatalf("could not load the generated code: %s\n%s", err, code)
+		}
+
+		if err := machine.Run(); nil != err {
+			return "", err
+		}
+
+		outputs = append(outputs, out.String())
+	}
+
+	if outputs[0] != outputs[1] {
+		t.Fatalf("generated and parsed code differ, generated=%q, parsed=%q", outputs[0], outputs[1])
+	}
+
+	return outputs[0], nil
+}
+
+func TestGenerate(t *testing.T) {
+	input := `program example;
+var x: integer;
+var y: real := 1;
+procedure p(a: integer);
+var b: integer;
+begin
+	b := a * 2;
+	if b > 2 then write(b) end
+end;
+begin
+	read(x);
+	while x > 0 do
+		begin
+			p(x);
+			x := x - 1
+		end;
+	writeln(y / 2)
+end.`
+
+	expected := `     INPP
+     AMEM 2
+     CRCT 0
+     ARMZ 0,0
+     CRCT 1
+     CNVR
+     ARMZ 0,1
+     DSVS L1
+L2   ENPR 1
+     AMEM 1
+     CRCT 0
+     ARMZ 1,0
+     CRVL 1,-3
+     CRCT 2
+     MULT
+     ARMZ 1,0
+     CRVL 1,0
+     CRCT 2
+     CMMA
+     DSVF L3
+     CRVL 1,0
+     IMPR
+L3   NADA
+     DMEM 1
+     RTPR 1,1
+L1   NADA
+     LEIT
+     ARMZ 0,0
+L4   NADA
+     CRVL 0,0
+     CRCT 0
+     CMMA
+     DSVF L5
+     CRVL 0,0
+     CHPR L2
+     CRVL 0,0
+     CRCT 1
+     SUBT
+     ARMZ 0,0
+     DSVS L4
+L5   NADA
+     CRVL 0,1
+     CRCT 2
+     DIVR
+     IMPR
+     IMPL
+     DMEM 2
+     PARA
+`
+
+	code, err := testGenerate(t, input)
+
+	if nil != err {
+		t.Fatalf("generator error: %s", err)
+	}
+
+	if code.String() != expected {
+		t.Errorf("wrong code, expected=\n%s\ngot=\n%s", expected, code)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var x: integer := 2.5;", "1:19: cannot use real value as integer in declaration of x"},
+		{"x := 1;", "1:1: undeclared identifier x"},
+		{"procedure f; begin procedure g; begin end end", "1:20: nested procedures are not supported"},
+		{"var x: integer; writeln('x = ', x)", "1:25: strings are not supported by MEPA"},
+	}
+
+	for _, tt := range tests {
+		_, err := testGenerate(t, tt.input)
+
+		if nil == err {
+			t.Fatalf("expected an error for %q", tt.input)
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error for %q, expected=%q, got=%q", tt.input, tt.expected, err.Error())
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"INPP\nAMEM 1\nL1: nada\n  CRVL 0, 0\n\nPARA", "     INPP\n     AMEM 1\nL1   NADA\n     CRVL 0,0\n     PARA\n"},
+		{"     INPP\nL1   DSVS L1\n", "     INPP\nL1   DSVS L1\n"},
+	}
+
+	for _, tt := range tests {
+		code, err := Parse(tt.input)
+
+		if nil != err {
+			t.Fatalf("parse error for %q: %s", tt.input, err)
+		}
+
+		if code.String() != tt.expected {
+			t.Errorf("wrong code for %q, expected=%q, got=%q", tt.input, tt.expected, code.String())
+		}
+	}
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{"L1   FOO 1", "line 1: unknown instruction FOO"},
+		{"     INPP\n     CRVL 0", "line 2: CRVL takes 2 operands, got 1"},
+		{"L1", "line 1: label L1 without an instruction"},
+	}
+
+	for _, tt := range errors {
+		_, err := Parse(tt.input)
+
+		if nil == err || err.Error() != tt.expected {
+			t.Errorf("wrong error for %q, expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestMachineErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"     INPP\n     CRCT 1\n     CRCT 0\n     DIVI\n     PARA", "instruction 3 (DIVI): division by zero"},
+		{"     INPP\n     SOMA\n     PARA", "instruction 1 (SOMA): stack underflow"},
+		{"     INPP\n     DSVS L9\n     PARA", "instruction 1 (DSVS L9): undefined label L9"},
+		{"     INPP\n     CRCT 1", "program ended without PARA"},
+		{"     INPP\n     LEIT\n     PARA", "instruction 1 (LEIT): could not read: EOF"},
+		{"     INPP\n     CRCT 9223372036854775807\n     CRCT 1\n     SOMA\n     PARA", "instruction 3 (SOMA): integer overflow, 9223372036854775807 + 1 does not fit in 64 bits"},
+		{"     INPP\nL1   CRCT 1\n     DSVS L1\n     PARA", "instruction 1 (L1   CRCT 1): stack overflow"},
+		{"     INPP\n     AMEM 2000000\n     PARA", "instruction 1 (AMEM 2000000): stack overflow"},
+	}
+
+	for _, tt := range tests {
+		code, err := Parse(tt.input)
+
+		if nil != err {
+			t.Fatalf("parse error for %q: %s", tt.input, err)
+		}
+
+		machine, err := InitializeMachine(code, strings.NewReader(""), &bytes.Buffer{})
+
+		if nil != err {
+			t.Fatalf("load error for %q: %s", tt.input, err)
+		}
+
+		err = machine.Run()
+
+		if nil == err || err.Error() != tt.expected {
+			t.Errorf("wrong error for %q, expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+
+	if _, err := InitializeMachine(Code{{Label: "L1", Mnemonic: NADA}, {Label: "L1", Mnemonic: NADA}}, nil, nil); nil == err {
+		t.Errorf("expected an error for a label defined twice")
+	}
+}
+
+// TestRead : the input is parsed with the type of the variable it is read into
+func TestRead(t *testing.T) {
+	tests := []struct {
+		input    string
+		stdin    string
+		expected string
+	}{
+		{"var x: integer; read(x); writeln(x)", "9007199254740993", "9007199254740993\n"},
+		{"var x: integer; read(x); writeln(x div 2)", "2.5", `instruction 4 (LEIT): could not read: "2.5" is not an integer`},
+		{"var y: real; var x: integer; read(y, x); writeln(y / x)", "3 2", "1.5\n"},
+	}
+
+	for _, tt := range tests {
+		output, err := testExecute(t, tt.input, tt.stdin)
+
+		if nil != err {
+			output = err.Error()
+		}
+
+		if output != tt.expected {
+			t.Errorf("wrong result for %q with input %q, expected=%q, got=%q", tt.input, tt.stdin, tt.expected, output)
+		}
+	}
+}
+
+// TestStackOverflow : runaway recursion stops the machine instead of taking all the memory there is
+func TestStackOverflow(t *testing.T) {
+	_, err := testExecute(t, "procedure f(k: integer); begin f(k + 1) end; f(0)", "")
+
+	if nil == err || !strings.HasSuffix(err.Error(), "stack overflow") {
+		t.Errorf("expected a stack overflow, got=%v", err)
+	}
+}
+
+// TestMatchesEvaluator : programs must write exactly what the tree-walking evaluator writes
+func TestMatchesEvaluator(t *testing.T) {
+	tests := []struct {
+		input string
+		stdin string
+	}{
+		{
+			`program loops;
+var i: integer;
+var total: integer := 0;
+begin
+	for i := 1 to 10 do
+		total := total + i;
+	writeln(total);
+	writeln(i);
+	for i := 5 to 1 do
+		total := 0;
+	writeln(i);
+	while total > 40 do
+		begin
+			total := total - 7;
+			write(total);
+		end;
+	writeln
+end.`,
+			"",
+		},
+		{
+			`program blocks;
+var t: integer := 0;
+procedure f(k: integer);
+	begin
+		if k > 2 then writeln(k) end else begin t := k + 1; f(t) end end
+	end;
+begin
+	f(0);
+	begin
+		t := t * 2;
+		writeln(t)
+	end
+end.`,
+			"",
+		},
+		{
+			`program largest;
+var i: integer;
+begin
+	for i := 9223372036854775806 to 9223372036854775807 do writeln(i);
+	writeln(i)
+end.`,
+			"",
+		},
+		{
+			`program conditionals;
+var a: integer;
+var b: real;
+begin
+	readln(a);
+	read(b);
+	if a > b then writeln(a) end else writeln(b) end;
+	if (a = 3) and not (b > 10) then writeln(a * b, a / 2, 7 div 2, -7 mod 3) end
+end.`,
+			"3 ignored\n2\n",
+		},
+		{
+			`program procedures;
+var counter: integer := 0;
+procedure increment(by: integer);
+	var half: real;
+	begin
+		half := by / 2;
+		counter := counter + by;
+		writeln(half)
+	end;
+procedure twice(by: integer);
+	begin
+		increment(by);
+		increment(by)
+	end;
+begin
+	twice(3);
+	writeln(counter)
+end.`,
+			"",
+		},
+		{
+			`program shadowing;
+var x: integer := 1;
+procedure show(x: real, y: integer);
+	begin
+		writeln(x, y)
+	end;
+begin
+	show(x + 1, 5);
+	writeln(x)
+end.`,
+			"",
+		},
+		{
+			`program recursion;
+var n: integer;
+var result: integer := 1;
+procedure factorial(k: integer);
+	begin
+		if k > 1 then
+			result := result * k;
+			factorial(k - 1)
+		end
+	end;
+procedure countdown(k: integer);
+	var i: integer;
+	begin
+		for i := 1 to k do
+			write(k - i);
+		writeln
+	end;
+begin
+	read(n);
+	factorial(n);
+	writeln(result);
+	countdown(n)
+end.`,
+			"6",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.InitializeLexer(tt.input)
+		p := parser.InitializeParser(l)
+
+		var expected bytes.Buffer
+
+		e := evaluator.InitializeEvaluator(strings.NewReader(tt.stdin), &expected)
+
+		if failure, ok := e.Eval(p.ParseProgram(), object.InitializeEnvironment()).(*object.Error); ok {
+			t.Fatalf("evaluator error: %s", failure.Inspect())
+		}
+
+		output, err := testExecute(t, tt.input, tt.stdin)
+
+		if nil != err {
+			t.Fatalf("machine error for %q: %s", tt.input, err)
+		}
+
+		if output != expected.String() {
+			t.Errorf("machine output differs from the evaluator, expected=%q, got=%q", expected.String(), output)
+		}
+	}
+}
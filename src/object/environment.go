@@ -0,0 +1,72 @@
+package object
+
+import (
+	"fmt"
+)
+
+// Environment : scope holding variables, constants and procedures, chained to the scope it was opened in
+type Environment struct {
+	store     map[string]Object
+	constants map[string]bool
+	outer     *Environment
+}
+
+// Get : looks the name up in this scope and then in the enclosing ones
+func (e *Environment) Get(name string) (Object, bool) {
+	value, ok := e.store[name]
+
+	if !ok && nil != e.outer {
+		return e.outer.Get(name)
+	}
+
+	return value, ok
+}
+
+// Declare : binds the name in this scope, shadowing any enclosing binding
+func (e *Environment) Declare(name string, value Object, constant bool) {
+	e.store[name] = value
+	e.constants[name] = constant
+}
+
+// Assign : updates the innermost binding of the name, converting the value to the type the binding already holds
+func (e *Environment) Assign(name string, value Object) (Object, error) {
+	current, ok := e.store[name]
+
+	if !ok {
+		if nil == e.outer {
+			return nil, fmt.Errorf("identifier not found: %s", name)
+		}
+
+		return e.outer.Assign(name, value)
+	}
+
+	if e.constants[name] {
+		return nil, fmt.Errorf("cannot assign to constant %s", name)
+	}
+
+	converted, err := Convert(current, value)
+
+	if nil != err {
+		return nil, fmt.Errorf("cannot assign to %s: %s", name, err)
+	}
+
+	e.store[name] = converted
+
+	return converted, nil
+}
+
+// InitializeEnvironment :
+func InitializeEnvironment() *Environment {
+	return &Environment{
+		store:     make(map[string]Object),
+		constants: make(map[string]bool),
+	}
+}
+
+// InitializeEnclosedEnvironment :
+func InitializeEnclosedEnvironment(outer *Environment) *Environment {
+	env := InitializeEnvironment()
+	env.outer = outer
+
+	return env
+}
@@ -0,0 +1,106 @@
+package object
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode"
+)
+
+// Input : source of the read and readln statements, values are blank separated words
+type Input struct {
+	reader *bufio.Reader
+	// Bits of the integers read, larger ones being errors
+	width int
+}
+
+// word : next blank separated word
+func (in *Input) word() (string, error) {
+	word := []rune{}
+
+	for {
+		char, _, err := in.reader.ReadRune()
+
+		if nil != err {
+			if io.EOF == err && 0 != len(word) {
+				return string(word), nil
+			}
+
+			return "", err
+		}
+
+		if unicode.IsSpace(char) {
+			if 0 != len(word) {
+				in.reader.UnreadRune()
+
+				return string(word), nil
+			}
+
+			continue
+		}
+
+		word = append(word, char)
+	}
+}
+
+// Read : next word parsed according to the type of template, the value currently held by the variable read into
+func (in *Input) Read(template Object) (Object, error) {
+	word, err := in.word()
+
+	if nil != err {
+		return nil, err
+	}
+
+	if REAL_OBJ == template.Type() {
+		value, err := strconv.ParseFloat(word, 64)
+
+		if nil != err {
+			return nil, fmt.Errorf("%q is not a real", word)
+		}
+
+		return &Real{Value: value}, nil
+	}
+
+	value, err := strconv.ParseInt(word, 10, in.width)
+
+	if failure, ok := err.(*strconv.NumError); ok && strconv.ErrRange == failure.Err {
+		return nil, fmt.Errorf("%q does not fit in %d bits", word, in.width)
+	}
+
+	if nil != err {
+		return nil, fmt.Errorf("%q is not an integer", word)
+	}
+
+	return &Integer{Value: value}, nil
+}
+
+// SkipLine : discards what is left of the current line
+func (in *Input) SkipLine() {
+	for {
+		char, _, err := in.reader.ReadRune()
+
+		if nil != err || '\n' == char {
+			return
+		}
+	}
+}
+
+// InitializeInput : an existing *bufio.Reader is reused so no input gets buffered away from its other readers;
+// integers read must fit in width bits, zero meaning INTEGER_WIDTH
+func InitializeInput(in io.Reader, width int) *Input {
+	reader, ok := in.(*bufio.Reader)
+
+	if !ok {
+		reader = bufio.NewReader(in)
+	}
+
+	if 0 == width {
+		width = INTEGER_WIDTH
+	}
+
+	return &Input{
+		reader: reader,
+		width:  width,
+	}
+}
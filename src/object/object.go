@@ -0,0 +1,205 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"../ast"
+	"../code"
+	"../token"
+)
+
+// ObjectType :
+type ObjectType string
+
+const (
+	INTEGER_OBJ   = "INTEGER"
+	REAL_OBJ      = "REAL"
+	BOOLEAN_OBJ   = "BOOLEAN"
+	STRING_OBJ    = "STRING"
+	NULL_OBJ      = "NULL"
+	ERROR_OBJ     = "ERROR"
+	PROCEDURE_OBJ = "PROCEDURE"
+
+	COMPILED_PROCEDURE_OBJ = "COMPILED_PROCEDURE"
+)
+
+// Object : every value handled at runtime
+type Object interface {
+	Type() ObjectType
+	Inspect() string
+}
+
+// Integer :
+type Integer struct {
+	Value int64
+}
+
+// Real :
+type Real struct {
+	Value float64
+}
+
+// Boolean : result of the relational and logical operators
+type Boolean struct {
+	Value bool
+}
+
+// String : only ever written, there are no string variables
+type String struct {
+	Value string
+}
+
+// Null : value of the statements that do not produce anything
+type Null struct{}
+
+// Error : runtime error, stops the execution
+type Error struct {
+	Message  string
+	Position token.Position
+}
+
+// Procedure : user declared procedure closed over the environment it was declared in
+type Procedure struct {
+	Name         string
+	Parameters   []*ast.Identifier
+	Declarations []ast.Statement
+	Body         *ast.BlockStatement
+	Env          *Environment
+}
+
+// CompiledProcedure : procedure lowered to bytecode, arguments are converted to the type of their parameter zero
+type CompiledProcedure struct {
+	Name         string
+	Instructions code.Instructions
+	// Source position of the instructions that can fail at runtime, by offset
+	Positions  map[int]token.Position
+	NumLocals  int
+	Parameters []Object
+}
+
+var (
+	NULL  = &Null{}
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+)
+
+// Type :
+func (i *Integer) Type() ObjectType {
+	return INTEGER_OBJ
+}
+
+// Inspect :
+func (i *Integer) Inspect() string {
+	return strconv.FormatInt(i.Value, 10)
+}
+
+// Type :
+func (s *String) Type() ObjectType {
+	return STRING_OBJ
+}
+
+// Inspect :
+func (s *String) Inspect() string {
+	return s.Value
+}
+
+// Type :
+func (r *Real) Type() ObjectType {
+	return REAL_OBJ
+}
+
+// Inspect : always shows the decimal point so reals are not mistaken by integers
+func (r *Real) Inspect() string {
+	inspected := strconv.FormatFloat(r.Value, 'f', -1, 64)
+
+	if !strings.ContainsAny(inspected, ".IN") {
+		inspected += ".0"
+	}
+
+	return inspected
+}
+
+// Type :
+func (b *Boolean) Type() ObjectType {
+	return BOOLEAN_OBJ
+}
+
+// Inspect :
+func (b *Boolean) Inspect() string {
+	if b.Value {
+		return "true"
+	}
+
+	return "false"
+}
+
+// Type :
+func (n *Null) Type() ObjectType {
+	return NULL_OBJ
+}
+
+// Inspect :
+func (n *Null) Inspect() string {
+	return "null"
+}
+
+// Type :
+func (e *Error) Type() ObjectType {
+	return ERROR_OBJ
+}
+
+// Inspect :
+func (e *Error) Inspect() string {
+	return fmt.Sprintf("%s: runtime error: %s", e.Position, e.Message)
+}
+
+// Error : lets the backends hand runtime errors around as Go errors
+func (e *Error) Error() string {
+	return e.Inspect()
+}
+
+// Type :
+func (p *Procedure) Type() ObjectType {
+	return PROCEDURE_OBJ
+}
+
+// Inspect :
+func (p *Procedure) Inspect() string {
+	var out bytes.Buffer
+
+	parameters := []string{}
+
+	for _, parameter := range p.Parameters {
+		parameters = append(parameters, parameter.String()+": "+parameter.Type.Literal)
+	}
+
+	out.WriteString("procedure ")
+	out.WriteString(p.Name)
+	out.WriteString("(")
+	out.WriteString(strings.Join(parameters, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// Type :
+func (cp *CompiledProcedure) Type() ObjectType {
+	return COMPILED_PROCEDURE_OBJ
+}
+
+// Inspect :
+func (cp *CompiledProcedure) Inspect() string {
+	return fmt.Sprintf("compiled procedure %s[%p]", cp.Name, cp)
+}
+
+// NativeBoolean : shared TRUE or FALSE instances
+func NativeBoolean(value bool) *Boolean {
+	if value {
+		return TRUE
+	}
+
+	return FALSE
+}
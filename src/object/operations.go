@@ -0,0 +1,220 @@
+package object
+
+import (
+	"fmt"
+	"math"
+
+	"../token"
+)
+
+// Operations shared by every backend, so the interpreter and the virtual machine agree on the LALG semantics:
+// integers widen to reals when mixed, "/" always yields a real, "div" and "mod" only take integers and the relational
+// operators yield booleans
+
+// INTEGER_WIDTH : bits of the integers when no other width is given, those of an int64
+const INTEGER_WIDTH = 64
+
+// Arithmetic : the operators on integers of a given width, results that do not fit being errors rather than wrapping
+// around as Go integers do
+type Arithmetic struct {
+	// Bits of the integers, 16, 32 or 64; zero means INTEGER_WIDTH
+	Width int
+}
+
+// Zero : initial value of a variable declared with the given type keyword
+func Zero(t token.TokenType) Object {
+	if token.REAL_KEYWORD == t {
+		return &Real{Value: 0}
+	}
+
+	return &Integer{Value: 0}
+}
+
+// Convert : value ready to be stored where target is, integers widen to reals but reals never narrow to integers
+func Convert(target Object, value Object) (Object, error) {
+	switch target.(type) {
+	case *Integer:
+		if integer, ok := value.(*Integer); ok {
+			return integer, nil
+		}
+	case *Real:
+		switch number := value.(type) {
+		case *Real:
+			return number, nil
+		case *Integer:
+			return &Real{Value: float64(number.Value)}, nil
+		}
+	default:
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("type mismatch: %s to %s", value.Type(), target.Type())
+}
+
+// Condition : value of an if or while condition, which must be a boolean
+func Condition(value Object) (bool, error) {
+	if boolean, ok := value.(*Boolean); ok {
+		return boolean.Value, nil
+	}
+
+	return false, fmt.Errorf("condition is not a boolean, got %s", value.Type())
+}
+
+// width :
+func (a Arithmetic) width() int {
+	if 0 == a.Width {
+		return INTEGER_WIDTH
+	}
+
+	return a.Width
+}
+
+// integer : the result, unless it does not fit in the width or the int64 computing it already overflowed
+func (a Arithmetic) integer(value int64, overflow bool, format string, operands ...interface{}) (Object, error) {
+	largest := int64(math.MaxInt64) >> uint(INTEGER_WIDTH-a.width())
+
+	if overflow || value > largest || value < -largest-1 {
+		return nil, fmt.Errorf("integer overflow, %s does not fit in %d bits", fmt.Sprintf(format, operands...), a.width())
+	}
+
+	return &Integer{Value: value}, nil
+}
+
+// Unary : applies a prefix operator
+func (a Arithmetic) Unary(operator token.TokenType, right Object) (Object, error) {
+	switch operator {
+	case token.MINUS:
+		switch number := right.(type) {
+		case *Integer:
+			return a.integer(-number.Value, math.MinInt64 == number.Value, "-(%d)", number.Value)
+		case *Real:
+			return &Real{Value: -number.Value}, nil
+		}
+	case token.NOT:
+		if boolean, ok := right.(*Boolean); ok {
+			return NativeBoolean(!boolean.Value), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown operator: %s %s", operator, right.Type())
+}
+
+// Binary : applies an infix operator
+func (a Arithmetic) Binary(operator token.TokenType, left Object, right Object) (Object, error) {
+	switch {
+	case INTEGER_OBJ == left.Type() && INTEGER_OBJ == right.Type():
+		return a.integerBinary(operator, left.(*Integer).Value, right.(*Integer).Value)
+	case isNumber(left) && isNumber(right):
+		return realBinary(operator, toReal(left), toReal(right))
+	case BOOLEAN_OBJ == left.Type() && BOOLEAN_OBJ == right.Type():
+		return booleanBinary(operator, left.(*Boolean).Value, right.(*Boolean).Value)
+	}
+
+	return nil, fmt.Errorf("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+}
+
+// isNumber :
+func isNumber(value Object) bool {
+	return INTEGER_OBJ == value.Type() || REAL_OBJ == value.Type()
+}
+
+// toReal :
+func toReal(value Object) float64 {
+	if integer, ok := value.(*Integer); ok {
+		return float64(integer.Value)
+	}
+
+	return value.(*Real).Value
+}
+
+// integerBinary : the int64 result is checked against the operands, as it wraps around when they are large enough
+func (a Arithmetic) integerBinary(operator token.TokenType, left int64, right int64) (Object, error) {
+	switch operator {
+	case token.PLUS:
+		sum := left + right
+
+		return a.integer(sum, (right > 0 && sum < left) || (right < 0 && sum > left), "%d + %d", left, right)
+	case token.MINUS:
+		difference := left - right
+
+		return a.integer(difference, (right > 0 && difference > left) || (right < 0 && difference < left), "%d - %d", left, right)
+	case token.ASTERISK:
+		product := left * right
+
+		return a.integer(product, 0 != left && (product/left != right || (-1 == left && math.MinInt64 == right)), "%d * %d", left, right)
+	case token.SLASH:
+		return realBinary(operator, float64(left), float64(right))
+	case token.DIV, token.MOD:
+		if 0 == right {
+			return nil, fmt.Errorf("division by zero")
+		}
+
+		if token.DIV == operator {
+			return a.integer(left/right, math.MinInt64 == left && -1 == right, "%d div %d", left, right)
+		}
+
+		return &Integer{Value: left % right}, nil
+	case token.EQUAL:
+		return NativeBoolean(left == right), nil
+	case token.DIFFERENT:
+		return NativeBoolean(left != right), nil
+	case token.LESS_THAN:
+		return NativeBoolean(left < right), nil
+	case token.LESS_THAN_EQUAL:
+		return NativeBoolean(left <= right), nil
+	case token.GREATER_THAN:
+		return NativeBoolean(left > right), nil
+	case token.GREATER_THAN_EQUAL:
+		return NativeBoolean(left >= right), nil
+	}
+
+	return nil, fmt.Errorf("unknown operator: %s %s %s", INTEGER_OBJ, operator, INTEGER_OBJ)
+}
+
+// realBinary :
+func realBinary(operator token.TokenType, left float64, right float64) (Object, error) {
+	switch operator {
+	case token.PLUS:
+		return &Real{Value: left + right}, nil
+	case token.MINUS:
+		return &Real{Value: left - right}, nil
+	case token.ASTERISK:
+		return &Real{Value: left * right}, nil
+	case token.SLASH:
+		if 0 == right {
+			return nil, fmt.Errorf("division by zero")
+		}
+
+		return &Real{Value: left / right}, nil
+	case token.EQUAL:
+		return NativeBoolean(left == right), nil
+	case token.DIFFERENT:
+		return NativeBoolean(left != right), nil
+	case token.LESS_THAN:
+		return NativeBoolean(left < right), nil
+	case token.LESS_THAN_EQUAL:
+		return NativeBoolean(left <= right), nil
+	case token.GREATER_THAN:
+		return NativeBoolean(left > right), nil
+	case token.GREATER_THAN_EQUAL:
+		return NativeBoolean(left >= right), nil
+	}
+
+	return nil, fmt.Errorf("unknown operator: %s %s %s", REAL_OBJ, operator, REAL_OBJ)
+}
+
+// booleanBinary : "and" and "or" evaluate both operands, as in standard Pascal
+func booleanBinary(operator token.TokenType, left bool, right bool) (Object, error) {
+	switch operator {
+	case token.AND:
+		return NativeBoolean(left && right), nil
+	case token.OR:
+		return NativeBoolean(left || right), nil
+	case token.EQUAL:
+		return NativeBoolean(left == right), nil
+	case token.DIFFERENT:
+		return NativeBoolean(left != right), nil
+	}
+
+	return nil, fmt.Errorf("unknown operator: %s %s %s", BOOLEAN_OBJ, operator, BOOLEAN_OBJ)
+}
@@ -2,40 +2,147 @@ package parser
 
 import (
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 
 	"../ast"
+	"../diagnostic"
 	"../lexer"
 	"../token"
 )
 
+// Pascal-style precedence: every relational operator shares the lowest level, "or" sits with the adding operators
+// and "and" with the multiplying ones
 const (
-	_           int = iota
-	LOWEST          // Starting condition
-	EQUALS          // ==
-	LESSGREATER     // > or <
-	SUM             // +
-	PRODUCT         // *
-	PREFIX          // -X or !X
-	CALL            // myFunction(X)
+	_          int = iota
+	LOWEST         // Starting condition
+	RELATIONAL     // = <> < <= > >=
+	SUM            // + - or
+	PRODUCT        // * / div mod and
+	PREFIX         // -X or not X
+	CALL           // myFunction(X)
 )
 
 var precedences = map[token.TokenType]int{
-	token.EQUAL:            EQUALS,
-	token.DIFFERENT:        EQUALS,
-	token.LESS_THAN:        LESSGREATER,
-	token.GREATER_THAN:     LESSGREATER,
-	token.PLUS:             SUM,
-	token.MINUS:            SUM,
-	token.SLASH:            PRODUCT,
-	token.ASTERISK:         PRODUCT,
-	token.LEFT_PARENTHESIS: CALL,
+	token.EQUAL:              RELATIONAL,
+	token.DIFFERENT:          RELATIONAL,
+	token.LESS_THAN:          RELATIONAL,
+	token.LESS_THAN_EQUAL:    RELATIONAL,
+	token.GREATER_THAN:       RELATIONAL,
+	token.GREATER_THAN_EQUAL: RELATIONAL,
+	token.PLUS:               SUM,
+	token.MINUS:              SUM,
+	token.OR:                 SUM,
+	token.SLASH:              PRODUCT,
+	token.ASTERISK:           PRODUCT,
+	token.DIV:                PRODUCT,
+	token.MOD:                PRODUCT,
+	token.AND:                PRODUCT,
+	token.LEFT_PARENTHESIS:   CALL,
+}
+
+// Precedence : how tightly the infix operator binds its operands, LOWEST for any other token
+func Precedence(t token.TokenType) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+
+	return LOWEST
+}
+
+// tokenSet :
+type tokenSet map[token.TokenType]bool
+
+// synchronizingSet : panic mode recovery stops once the current token terminates the broken construct or the next one
+// follows it, terminators are taken from the FOLLOW set and followers from the FIRST set of what may come next
+type synchronizingSet struct {
+	terminators tokenSet
+	followers   tokenSet
+}
+
+// statementFirst : tokens that may start a new declaration or statement
+var statementFirst = tokenSet{
+	token.END:       true,
+	token.ELSE:      true,
+	token.BEGIN:     true,
+	token.IF:        true,
+	token.WHILE:     true,
+	token.FOR:       true,
+	token.READ:      true,
+	token.READLN:    true,
+	token.WRITE:     true,
+	token.WRITELN:   true,
+	token.VAR:       true,
+	token.CONST:     true,
+	token.PROCEDURE: true,
+	token.PROGRAM:   true,
+}
+
+var (
+	declarationSynchronizingSet = synchronizingSet{
+		terminators: tokenSet{
+			token.SEMICOLON: true,
+		},
+		followers: tokenSet{
+			token.VAR:       true,
+			token.CONST:     true,
+			token.PROCEDURE: true,
+			token.BEGIN:     true,
+			token.PROGRAM:   true,
+		},
+	}
+	statementSynchronizingSet = synchronizingSet{
+		terminators: tokenSet{
+			token.SEMICOLON: true,
+		},
+		followers: statementFirst,
+	}
+	conditionSynchronizingSet = synchronizingSet{
+		terminators: tokenSet{
+			token.THEN: true,
+			token.DO:   true,
+		},
+		followers: union(statementFirst, tokenSet{
+			token.THEN: true,
+			token.DO:   true,
+		}),
+	}
+	procedureSynchronizingSet = synchronizingSet{
+		terminators: tokenSet{},
+		followers: tokenSet{
+			token.PROCEDURE: true,
+			token.BEGIN:     true,
+		},
+	}
+	parameterSynchronizingSet = synchronizingSet{
+		terminators: tokenSet{},
+		followers: tokenSet{
+			token.COMMA:             true,
+			token.RIGHT_PARENTHESIS: true,
+			token.SEMICOLON:         true,
+			token.BEGIN:             true,
+		},
+	}
+)
+
+// union :
+func union(sets ...tokenSet) tokenSet {
+	result := tokenSet{}
+
+	for _, set := range sets {
+		for t := range set {
+			result[t] = true
+		}
+	}
+
+	return result
 }
 
 // Parser :
 type Parser struct {
-	l      *lexer.Lexer
-	errors []string
+	l           *lexer.Lexer
+	diagnostics diagnostic.List
 
 	currentToken token.Token
 	peekToken    token.Token
@@ -77,11 +184,7 @@ func (p *Parser) peekTokenIs(t token.TokenType) bool {
 
 // peekPrecedence :
 func (p *Parser) peekPrecedence() int {
-	if p, ok := precedences[p.peekToken.Type]; ok {
-		return p
-	}
-
-	return LOWEST
+	return Precedence(p.peekToken.Type)
 }
 
 // expectPeek :
@@ -99,28 +202,19 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 
 // expectType :
 func (p *Parser) expectType() token.Token {
-	if p.peekTokenIs(token.INTEGER_KEYWORD) {
-		p.nextToken()
-
-		return token.Token{
-			Type:    "INTEGER_KEYWORD",
-			Literal: "integer",
-		}
-	}
-	if p.peekTokenIs(token.REAL_KEYWORD) {
+	if p.peekTokenIs(token.INTEGER_KEYWORD) || p.peekTokenIs(token.REAL_KEYWORD) {
 		p.nextToken()
 
-		return token.Token{
-			Type:    "REAL_KEYWORD",
-			Literal: "real",
-		}
+		return p.currentToken
 	}
 
+	p.peekErrors(token.INTEGER_KEYWORD, token.REAL_KEYWORD)
 	p.nextToken()
 
 	return token.Token{
-		Type:    "ILLEGAL",
-		Literal: "",
+		Type:     token.ILLEGAL,
+		Literal:  "",
+		Position: p.currentToken.Position,
 	}
 }
 
@@ -136,6 +230,10 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 	leftExpression := prefix()
 
+	if nil == leftExpression {
+		return nil
+	}
+
 	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
 		infix := p.infixParserFunction[p.peekToken.Type]
 
@@ -146,6 +244,10 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		p.nextToken()
 
 		leftExpression = infix(leftExpression)
+
+		if nil == leftExpression {
+			return nil
+		}
 	}
 
 	return leftExpression
@@ -172,10 +274,17 @@ func (p *Parser) parseVarStatement() *ast.VarStatement {
 
 	statement.Type = p.expectType()
 
-	if "ILLEGAL" == statement.Type.Type {
+	if token.ILLEGAL == statement.Type.Type {
 		return nil
 	}
 
+	// variables may be declared without an initial value
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+
+		return statement
+	}
+
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
@@ -184,7 +293,7 @@ func (p *Parser) parseVarStatement() *ast.VarStatement {
 
 	statement.Value = p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.SEMICOLON) {
+	if nil == statement.Value || !p.expectPeek(token.SEMICOLON) {
 		return nil
 	}
 
@@ -212,7 +321,7 @@ func (p *Parser) parseConstStatement() *ast.ConstStatement {
 
 	statement.Type = p.expectType()
 
-	if "ILLEGAL" == statement.Type.Type {
+	if token.ILLEGAL == statement.Type.Type {
 		return nil
 	}
 
@@ -224,7 +333,7 @@ func (p *Parser) parseConstStatement() *ast.ConstStatement {
 
 	statement.Value = p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.SEMICOLON) {
+	if nil == statement.Value || !p.expectPeek(token.SEMICOLON) {
 		return nil
 	}
 
@@ -239,16 +348,218 @@ func (p *Parser) parseIdentifier() ast.Expression {
 	}
 }
 
+// synchronize : panic mode recovery, discards tokens until a safe point is reached so the caller can carry on
+func (p *Parser) synchronize(synchronizing synchronizingSet) {
+	for !p.currentTokenIs(token.EOF) && !p.peekTokenIs(token.EOF) {
+		if synchronizing.terminators[p.currentToken.Type] || synchronizing.followers[p.peekToken.Type] {
+			return
+		}
+
+		p.nextToken()
+	}
+}
+
+// terminator : the semicolon ending a statement, which only the last one of a block or of the input may omit; a
+// missing one is reported but the statement is kept
+func (p *Parser) terminator() {
+	switch {
+	case p.peekTokenIs(token.SEMICOLON):
+		p.nextToken()
+	case p.peekTokenIs(token.END), p.peekTokenIs(token.ELSE), p.peekTokenIs(token.EOF):
+	default:
+		p.peekErrors(token.SEMICOLON)
+	}
+}
+
+// terminated : expressions whose semicolon was already taken, by the declaration itself or the statement ending the
+// loop body
+func terminated(expression ast.Expression) bool {
+	switch expression := expression.(type) {
+	case *ast.ProcedureLiteral, *ast.ProgramLiteral:
+		return true
+	case *ast.WhileLiteral:
+		_, block := expression.Body.(*ast.BlockStatement)
+
+		return !block
+	case *ast.ForLiteral:
+		_, block := expression.Body.(*ast.BlockStatement)
+
+		return !block
+	}
+
+	return false
+}
+
+// parseAssignStatement :
+func (p *Parser) parseAssignStatement() *ast.AssignStatement {
+	statement := &ast.AssignStatement{
+		Name: &ast.Identifier{
+			Token: p.currentToken,
+			Value: p.currentToken.Literal,
+		},
+	}
+
+	if !p.expectPeek(token.ASSIGN) {
+		return nil
+	}
+
+	statement.Token = p.currentToken
+
+	p.nextToken()
+
+	statement.Value = p.parseExpression(LOWEST)
+
+	if nil == statement.Value {
+		return nil
+	}
+
+	p.terminator()
+
+	return statement
+}
+
+// parseInputOutputArguments : "(a, b)" for any of the I/O statements, the parentheses being optional for the "ln"
+// versions that may also be called without any argument
+func (p *Parser) parseInputOutputArguments() []ast.Expression {
+	name := p.currentToken.Literal
+	optional := p.currentTokenIs(token.READLN) || p.currentTokenIs(token.WRITELN)
+
+	if optional && !p.peekTokenIs(token.LEFT_PARENTHESIS) {
+		return []ast.Expression{}
+	}
+
+	if !p.expectPeek(token.LEFT_PARENTHESIS) {
+		return nil
+	}
+
+	if !optional && p.peekTokenIs(token.RIGHT_PARENTHESIS) {
+		message := fmt.Sprintf("%s expects at least one argument", name)
+		p.report(diagnostic.MISSING_ARGUMENTS, p.peekToken, nil, message)
+
+		return nil
+	}
+
+	return p.parseCallArguments()
+}
+
+// closingParenthesis : the parenthesis ending the arguments just parsed, missing when they were not parenthesized
+func (p *Parser) closingParenthesis() token.Token {
+	if p.currentTokenIs(token.RIGHT_PARENTHESIS) {
+		return p.currentToken
+	}
+
+	return token.Token{}
+}
+
+// parseReadStatement :
+func (p *Parser) parseReadStatement() *ast.ReadStatement {
+	statement := &ast.ReadStatement{
+		Token:     p.currentToken,
+		Arguments: []*ast.Identifier{},
+	}
+
+	arguments := p.parseInputOutputArguments()
+
+	if nil == arguments {
+		return nil
+	}
+
+	statement.RightParenthesis = p.closingParenthesis()
+
+	valid := true
+
+	for _, argument := range arguments {
+		identifier, ok := argument.(*ast.Identifier)
+
+		if !ok {
+			message := fmt.Sprintf("cannot %s into '%s', expected a variable", statement.Token.Literal, argument.String())
+			p.reportNode(diagnostic.INVALID_READ_TARGET, argument, []token.TokenType{token.IDENTIFIER}, message)
+
+			valid = false
+
+			continue
+		}
+
+		statement.Arguments = append(statement.Arguments, identifier)
+	}
+
+	if !valid {
+		return nil
+	}
+
+	p.terminator()
+
+	return statement
+}
+
+// parseWriteStatement :
+func (p *Parser) parseWriteStatement() *ast.WriteStatement {
+	statement := &ast.WriteStatement{
+		Token: p.currentToken,
+	}
+
+	statement.Arguments = p.parseInputOutputArguments()
+
+	if nil == statement.Arguments {
+		return nil
+	}
+
+	statement.RightParenthesis = p.closingParenthesis()
+
+	p.terminator()
+
+	return statement
+}
+
 // parseStatement :
 func (p *Parser) parseStatement() ast.Statement {
-	switch p.currentToken.Type {
-	case token.VAR:
-		return p.parseVarStatement()
-	case token.CONST:
-		return p.parseConstStatement()
+	switch {
+	case p.currentTokenIs(token.VAR):
+		if statement := p.parseVarStatement(); nil != statement {
+			return statement
+		}
+
+		p.synchronize(declarationSynchronizingSet)
+	case p.currentTokenIs(token.CONST):
+		if statement := p.parseConstStatement(); nil != statement {
+			return statement
+		}
+
+		p.synchronize(declarationSynchronizingSet)
+	case p.currentTokenIs(token.READ) || p.currentTokenIs(token.READLN):
+		if statement := p.parseReadStatement(); nil != statement {
+			return statement
+		}
+
+		p.synchronize(statementSynchronizingSet)
+	case p.currentTokenIs(token.WRITE) || p.currentTokenIs(token.WRITELN):
+		if statement := p.parseWriteStatement(); nil != statement {
+			return statement
+		}
+
+		p.synchronize(statementSynchronizingSet)
+	case p.currentTokenIs(token.IDENTIFIER) && p.peekTokenIs(token.ASSIGN):
+		if statement := p.parseAssignStatement(); nil != statement {
+			return statement
+		}
+
+		p.synchronize(statementSynchronizingSet)
+	case p.currentTokenIs(token.BEGIN):
+		// the missing "end" was already reported at the end of the input
+		if block := p.parseBlockStatement(); p.currentTokenIs(token.END) {
+			p.terminator()
+
+			return block
+		}
 	default:
-		return p.parseExpressionStatement()
+		if statement := p.parseExpressionStatement(); nil != statement {
+			return statement
+		}
+
+		p.synchronize(statementSynchronizingSet)
 	}
+
+	return nil
 }
 
 // parseIntegerLiteral :
@@ -257,11 +568,18 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 		Token: p.currentToken,
 	}
 
-	value, err := strconv.ParseInt(p.currentToken.Literal, 0, 64)
+	// the lexer lets these digits through, they only fit after a unary minus
+	if lexer.MostNegative(p.currentToken.Literal, p.l.IntegerWidth()) {
+		p.report(diagnostic.NUMBER_OUT_OF_RANGE, p.currentToken, nil, lexer.OutOfRange(p.currentToken.Literal, p.l.IntegerWidth()))
+
+		return nil
+	}
+
+	// always decimal, "010" being ten rather than an octal eight
+	value, err := strconv.ParseInt(strings.Replace(p.currentToken.Literal, "_", "", -1), 10, 64)
 
 	if nil != err {
-		message := fmt.Sprintf("could not parse '%q' as integer", p.currentToken.Literal)
-		p.errors = append(p.errors, message)
+		p.report(diagnostic.INVALID_INTEGER, p.currentToken, nil, fmt.Sprintf("could not parse %q as integer", p.currentToken.Literal))
 
 		return nil
 	}
@@ -277,11 +595,10 @@ func (p *Parser) parseRealLiteral() ast.Expression {
 		Token: p.currentToken,
 	}
 
-	value, err := strconv.ParseFloat(p.currentToken.Literal, 64)
+	value, err := strconv.ParseFloat(strings.Replace(p.currentToken.Literal, "_", "", -1), 64)
 
 	if nil != err {
-		message := fmt.Sprintf("could not parse '%q' as real", p.currentToken.Literal)
-		p.errors = append(p.errors, message)
+		p.report(diagnostic.INVALID_REAL, p.currentToken, nil, fmt.Sprintf("could not parse %q as real", p.currentToken.Literal))
 
 		return nil
 	}
@@ -291,10 +608,23 @@ func (p *Parser) parseRealLiteral() ast.Expression {
 	return literal
 }
 
-// noPrefixParserFnError :
+// parseStringLiteral :
+func (p *Parser) parseStringLiteral() ast.Expression {
+	literal := p.currentToken.Literal
+
+	return &ast.StringLiteral{
+		Token: p.currentToken,
+		Value: strings.Replace(literal[1:len(literal)-1], "''", "'", -1),
+	}
+}
+
+// noPrefixParserFnError : illegal tokens were already reported by the lexer
 func (p *Parser) noPrefixParserFnError(t token.TokenType) {
-	message := fmt.Sprintf("no prefix parse function for '%s' was found", t)
-	p.errors = append(p.errors, message)
+	if token.ILLEGAL == t {
+		return
+	}
+
+	p.report(diagnostic.NO_PREFIX_PARSE_FUNCTION, p.currentToken, nil, fmt.Sprintf("no prefix parse function for '%s' was found", t))
 }
 
 // parseExpressionStatement :
@@ -305,8 +635,12 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 	statement.Expression = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.SEMICOLON) {
-		p.nextToken()
+	if nil == statement.Expression {
+		return nil
+	}
+
+	if !terminated(statement.Expression) {
+		p.terminator()
 	}
 
 	return statement
@@ -321,18 +655,41 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 
 	p.nextToken()
 
+	if literal := p.mostNegative(expression.Token); nil != literal {
+		return literal
+	}
+
 	expression.Right = p.parseExpression(PREFIX)
 
+	if nil == expression.Right {
+		return nil
+	}
+
 	return expression
 }
 
-// currentPrecedence :
-func (p *Parser) currentPrecedence() int {
-	if p, ok := precedences[p.currentToken.Type]; ok {
-		return p
+// mostNegative : "-32768" in 16 bits as a single literal, its digits alone not fitting in an integer of the width;
+// nil for any other operand
+func (p *Parser) mostNegative(minus token.Token) *ast.IntegerLiteral {
+	width := p.l.IntegerWidth()
+
+	if token.MINUS != minus.Type || !p.currentTokenIs(token.INTEGER) || !lexer.MostNegative(p.currentToken.Literal, width) {
+		return nil
 	}
 
-	return LOWEST
+	return &ast.IntegerLiteral{
+		Token: token.Token{
+			Type:     token.INTEGER,
+			Literal:  minus.Literal + p.currentToken.Literal,
+			Position: minus.Position,
+		},
+		Value: math.MinInt64 >> uint(lexer.INTEGER_WIDTH-width),
+	}
+}
+
+// currentPrecedence :
+func (p *Parser) currentPrecedence() int {
+	return Precedence(p.currentToken.Type)
 }
 
 // parseInfixExpression :
@@ -349,19 +706,34 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 
 	expression.Right = p.parseExpression(precedence)
 
+	if nil == expression.Right {
+		return nil
+	}
+
 	return expression
 }
 
-// parseGroupedExpression :
+// parseGroupedExpression : the parentheses are kept in the span of the operations they group
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	left := p.currentToken
+
 	p.nextToken()
 
 	expression := p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.RIGHT_PARENTHESIS) {
+	if nil == expression || !p.expectPeek(token.RIGHT_PARENTHESIS) {
 		return nil
 	}
 
+	switch expression := expression.(type) {
+	case *ast.PrefixExpression:
+		expression.LeftParenthesis = left
+		expression.RightParenthesis = p.currentToken
+	case *ast.InfixExpression:
+		expression.LeftParenthesis = left
+		expression.RightParenthesis = p.currentToken
+	}
+
 	return expression
 }
 
@@ -384,6 +756,12 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		p.nextToken()
 	}
 
+	if p.currentTokenIs(token.EOF) {
+		p.currentErrors(token.END)
+	} else {
+		block.EndToken = p.currentToken
+	}
+
 	return block
 }
 
@@ -397,7 +775,12 @@ func (p *Parser) parseConditionalExpression() ast.Expression {
 
 	expression.Condition = p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.THEN) {
+	if nil == expression.Condition {
+		p.synchronize(conditionSynchronizingSet)
+	}
+
+	// the broken condition may have stopped right at the "then"
+	if !p.currentTokenIs(token.THEN) && !p.expectPeek(token.THEN) {
 		return nil
 	}
 
@@ -425,9 +808,11 @@ func (p *Parser) parseParameter() *ast.Identifier {
 		return nil
 	}
 
-	p.nextToken()
+	identifier.Type = p.expectType()
 
-	identifier.Type = p.currentToken
+	if token.ILLEGAL == identifier.Type.Type {
+		return nil
+	}
 
 	p.nextToken()
 
@@ -444,84 +829,201 @@ func (p *Parser) parseProcedureParameters() []*ast.Identifier {
 		return identifiers
 	}
 
-	identifier := p.parseParameter()
-	identifiers = append(identifiers, identifier)
-
-	for p.currentTokenIs(token.COMMA) {
+	for {
 		identifier := p.parseParameter()
-		identifiers = append(identifiers, identifier)
+
+		if nil == identifier {
+			p.synchronize(parameterSynchronizingSet)
+			p.nextToken()
+		} else {
+			identifiers = append(identifiers, identifier)
+		}
+
+		if !p.currentTokenIs(token.COMMA) {
+			break
+		}
 	}
 
 	if !p.currentTokenIs(token.RIGHT_PARENTHESIS) {
+		p.currentErrors(token.RIGHT_PARENTHESIS)
+
 		return nil
 	}
 
 	return identifiers
 }
 
-// parseProcedureLiteral :
-func (p *Parser) parseProcedureLiteral() ast.Expression {
-	literal := &ast.ProcedureLiteral{
-		Token: p.currentToken,
+// parseDeclarations : the optional const and var sections of a program or procedure
+func (p *Parser) parseDeclarations() []ast.Statement {
+	declarations := []ast.Statement{}
+
+	for p.peekTokenIs(token.VAR) || p.peekTokenIs(token.CONST) {
+		p.nextToken()
+
+		if declaration := p.parseStatement(); nil != declaration {
+			declarations = append(declarations, declaration)
+		}
 	}
 
+	return declarations
+}
+
+// parseProcedureHeader : "procedure name(parameters);", the parameters list being optional
+func (p *Parser) parseProcedureHeader(literal *ast.ProcedureLiteral) bool {
 	if !p.expectPeek(token.IDENTIFIER) {
-		return nil
+		return false
 	}
 
 	literal.Name = p.currentToken.Literal
 
-	if !p.expectPeek(token.LEFT_PARENTHESIS) {
-		return nil
+	if p.peekTokenIs(token.LEFT_PARENTHESIS) {
+		p.nextToken()
+
+		literal.Parameters = p.parseProcedureParameters()
+
+		if nil == literal.Parameters {
+			literal.Parameters = []*ast.Identifier{}
+
+			p.synchronize(parameterSynchronizingSet)
+
+			if p.peekTokenIs(token.RIGHT_PARENTHESIS) {
+				p.nextToken()
+			}
+		}
 	}
 
-	literal.Parameters = p.parseProcedureParameters()
+	return p.expectPeek(token.SEMICOLON)
+}
 
-	if !p.expectPeek(token.SEMICOLON) {
-		return nil
+// parseProcedureDeclaration :
+func (p *Parser) parseProcedureDeclaration() *ast.ProcedureLiteral {
+	literal := &ast.ProcedureLiteral{
+		Token:      p.currentToken,
+		Parameters: []*ast.Identifier{},
 	}
 
+	if !p.parseProcedureHeader(literal) {
+		p.synchronize(declarationSynchronizingSet)
+	}
+
+	literal.Declarations = p.parseDeclarations()
+
 	if !p.expectPeek(token.BEGIN) {
 		return nil
 	}
 
 	literal.Body = p.parseBlockStatement()
 
+	if !p.currentTokenIs(token.END) {
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
 	return literal
 }
 
-// parseProgramLiteral :
+// parseProcedureLiteral :
+func (p *Parser) parseProcedureLiteral() ast.Expression {
+	if literal := p.parseProcedureDeclaration(); nil != literal {
+		return literal
+	}
+
+	return nil
+}
+
+// parseProcedures : procedure declarations that come after the program declarations
+func (p *Parser) parseProcedures() []*ast.ProcedureLiteral {
+	procedures := []*ast.ProcedureLiteral{}
+
+	for p.peekTokenIs(token.PROCEDURE) {
+		p.nextToken()
+
+		if procedure := p.parseProcedureDeclaration(); nil != procedure {
+			procedures = append(procedures, procedure)
+		} else {
+			p.synchronize(procedureSynchronizingSet)
+		}
+	}
+
+	return procedures
+}
+
+// parseProgramLiteral : "program name;" followed by the declarations, procedures and the main body ending in a dot
 func (p *Parser) parseProgramLiteral() ast.Expression {
 	literal := &ast.ProgramLiteral{
 		Token: p.currentToken,
 	}
 
 	if !p.expectPeek(token.IDENTIFIER) {
+		p.synchronize(declarationSynchronizingSet)
+	} else {
+		literal.Name = p.currentToken.Literal
+
+		if !p.expectPeek(token.SEMICOLON) {
+			p.synchronize(declarationSynchronizingSet)
+		}
+	}
+
+	literal.Declarations = p.parseDeclarations()
+	literal.Procedures = p.parseProcedures()
+
+	if !p.expectPeek(token.BEGIN) {
 		return nil
 	}
 
-	literal.Name = p.currentToken.Literal
+	literal.Body = p.parseBlockStatement()
 
-	if !p.expectPeek(token.SEMICOLON) {
+	if !p.currentTokenIs(token.END) || !p.expectPeek(token.DOT) {
 		return nil
 	}
 
+	literal.Dot = p.currentToken
+
 	return literal
 }
 
+// parseBody : body of the loops, either a single statement or a "begin ... end" block
+func (p *Parser) parseBody() ast.Statement {
+	p.nextToken()
+
+	if !p.currentTokenIs(token.BEGIN) {
+		return p.parseStatement()
+	}
+
+	block := p.parseBlockStatement()
+
+	if !p.currentTokenIs(token.END) {
+		return nil
+	}
+
+	return block
+}
+
 // parseWhileLiteral :
 func (p *Parser) parseWhileLiteral() ast.Expression {
 	literal := &ast.WhileLiteral{
 		Token: p.currentToken,
 	}
 
-	if !p.expectPeek(token.LEFT_PARENTHESIS) {
+	p.nextToken()
+
+	literal.Condition = p.parseExpression(LOWEST)
+
+	if nil == literal.Condition {
+		p.synchronize(conditionSynchronizingSet)
+	}
+
+	// the broken condition may have stopped right at the "do"
+	if !p.currentTokenIs(token.DO) && !p.expectPeek(token.DO) {
 		return nil
 	}
 
-	literal.Condition = p.parseExpression(LOWEST)
+	literal.Body = p.parseBody()
 
-	if !p.expectPeek(token.DO) {
+	if nil == literal.Body || nil == literal.Condition {
 		return nil
 	}
 
@@ -538,40 +1040,36 @@ func (p *Parser) parseForLiteral() ast.Expression {
 		return nil
 	}
 
-	literal.Variable = p.currentToken.Literal
+	literal.Variable = &ast.Identifier{
+		Token: p.currentToken,
+		Value: p.currentToken.Literal,
+	}
 
-	if !p.expectPeek(token.TO) {
+	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
 
 	p.nextToken()
 
-	literal.Desired = p.currentToken.Literal
+	literal.From = p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.DO) {
+	if nil == literal.From || !p.expectPeek(token.TO) {
 		return nil
 	}
 
-	return literal
-}
-
-// parseCommentLiteral :
-func (p *Parser) parseCommentLiteral() ast.Expression {
-	literal := &ast.CommentLiteral{
-		Token: p.currentToken,
-	}
-	comments := []string{}
-
 	p.nextToken()
 
-	for !p.currentTokenIs(token.RIGHT_BRACES) && !p.currentTokenIs(token.EOF) {
-		comments = append(comments, p.currentToken.Literal)
-		p.nextToken()
+	literal.To = p.parseExpression(LOWEST)
+
+	if nil == literal.To || !p.expectPeek(token.DO) {
+		return nil
 	}
 
-	literal.Comments = comments
+	literal.Body = p.parseBody()
 
-	p.nextToken()
+	if nil == literal.Body {
+		return nil
+	}
 
 	return literal
 }
@@ -588,13 +1086,25 @@ func (p *Parser) parseCallArguments() []ast.Expression {
 
 	p.nextToken()
 
-	arguments = append(arguments, p.parseExpression(LOWEST))
+	argument := p.parseExpression(LOWEST)
+
+	if nil == argument {
+		return nil
+	}
+
+	arguments = append(arguments, argument)
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
 
-		arguments = append(arguments, p.parseExpression(LOWEST))
+		argument := p.parseExpression(LOWEST)
+
+		if nil == argument {
+			return nil
+		}
+
+		arguments = append(arguments, argument)
 	}
 
 	if !p.expectPeek(token.RIGHT_PARENTHESIS) {
@@ -612,18 +1122,69 @@ func (p *Parser) parseCallExpression(procedure ast.Expression) ast.Expression {
 	}
 	expression.Arguments = p.parseCallArguments()
 
+	if nil == expression.Arguments {
+		return nil
+	}
+
+	expression.RightParenthesis = p.currentToken
+
 	return expression
 }
 
+// report :
+func (p *Parser) report(code diagnostic.Code, found token.Token, expected []token.TokenType, message string) {
+	p.diagnostics = append(p.diagnostics, diagnostic.Diagnostic{
+		Severity: diagnostic.ERROR,
+		Code:     code,
+		Start:    found.Position,
+		End:      found.End(),
+		Expected: expected,
+		Found:    found,
+		Message:  message,
+	})
+}
+
+// reportNode : error spanning the whole source of the node rather than a single token
+func (p *Parser) reportNode(code diagnostic.Code, node ast.Node, expected []token.TokenType, message string) {
+	p.diagnostics = append(p.diagnostics, diagnostic.Diagnostic{
+		Severity: diagnostic.ERROR,
+		Code:     code,
+		Start:    node.Pos(),
+		End:      node.End(),
+		Expected: expected,
+		Message:  message,
+	})
+}
+
+// unexpectedToken :
+func (p *Parser) unexpectedToken(prefix string, found token.Token, expected []token.TokenType) {
+	message := fmt.Sprintf("%s to be %s, got '%s' instead", prefix, diagnostic.JoinTokenTypes(expected), found.Type)
+
+	p.report(diagnostic.UNEXPECTED_TOKEN, found, expected, message)
+}
+
 // peekErrors :
-func (p *Parser) peekErrors(t token.TokenType) {
-	message := fmt.Sprintf("Expected next token to be %s, got '%s' instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, message)
+func (p *Parser) peekErrors(expected ...token.TokenType) {
+	p.unexpectedToken("Expected next token", p.peekToken, expected)
+}
+
+// currentErrors :
+func (p *Parser) currentErrors(expected ...token.TokenType) {
+	p.unexpectedToken("Expected token", p.currentToken, expected)
+}
+
+// Diagnostics : lexical and syntax diagnostics sorted by their position in the source
+func (p *Parser) Diagnostics() diagnostic.List {
+	diagnostics := append(diagnostic.List{}, p.l.Diagnostics()...)
+	diagnostics = append(diagnostics, p.diagnostics...)
+	diagnostics.Sort()
+
+	return diagnostics
 }
 
-// Errors :
+// Errors : compatibility view of Diagnostics
 func (p *Parser) Errors() []string {
-	return p.errors
+	return p.Diagnostics().Strings()
 }
 
 // ParseProgram :
@@ -647,8 +1208,8 @@ func (p *Parser) ParseProgram() *ast.Program {
 // InitializeParser :
 func InitializeParser(l *lexer.Lexer) *Parser {
 	p := &Parser{
-		l:      l,
-		errors: []string{},
+		l:           l,
+		diagnostics: diagnostic.List{},
 	}
 
 	// Sets the current and peek tokens
@@ -661,23 +1222,30 @@ func InitializeParser(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.IDENTIFIER, p.parseIdentifier)
 	p.registerPrefix(token.INTEGER, p.parseIntegerLiteral)
 	p.registerPrefix(token.REAL, p.parseRealLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.NOT, p.parsePrefixExpression)
 	p.registerPrefix(token.LEFT_PARENTHESIS, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseConditionalExpression)
 	p.registerPrefix(token.PROCEDURE, p.parseProcedureLiteral)
 	p.registerPrefix(token.PROGRAM, p.parseProgramLiteral)
 	p.registerPrefix(token.WHILE, p.parseWhileLiteral)
 	p.registerPrefix(token.FOR, p.parseForLiteral)
-	p.registerPrefix(token.LEFT_BRACES, p.parseCommentLiteral)
 
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.DIV, p.parseInfixExpression)
+	p.registerInfix(token.MOD, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
 	p.registerInfix(token.EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.DIFFERENT, p.parseInfixExpression)
 	p.registerInfix(token.LESS_THAN, p.parseInfixExpression)
+	p.registerInfix(token.LESS_THAN_EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.GREATER_THAN, p.parseInfixExpression)
+	p.registerInfix(token.GREATER_THAN_EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.LEFT_PARENTHESIS, p.parseCallExpression)
 
 	return p
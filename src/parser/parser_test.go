@@ -3,10 +3,13 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"testing"
 
 	"../ast"
+	"../diagnostic"
 	"../lexer"
+	"../token"
 )
 
 // testVarStatements :
@@ -251,6 +254,22 @@ func TestVarStatements(t *testing.T) {
 			return
 		}
 	}
+
+	input := "var bar: integer;"
+
+	l := lexer.InitializeLexer(input)
+	p := InitializeParser(l)
+	program := p.ParseProgram()
+
+	checkParserErrors(t, p)
+
+	if 1 != len(program.Statements) || !testVarStatements(t, program.Statements[0], "bar") {
+		t.Fatalf("var statement without value not parsed, got=%q", program.String())
+	}
+
+	if nil != program.Statements[0].(*ast.VarStatement).Value {
+		t.Errorf("var statement without value has a value, got=%q", program.String())
+	}
 }
 
 // TestConstStatements :
@@ -368,6 +387,150 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+// TestMostNegativeInteger :
+func TestMostNegativeInteger(t *testing.T) {
+	tests := []struct {
+		width    int
+		input    string
+		expected string
+	}{
+		{64, "-9223372036854775808;", "-9223372036854775808"},
+		{64, "a - -9_223_372_036_854_775_808 * 2;", "(a - (-9_223_372_036_854_775_808 * 2))"},
+		{64, "-(-9223372036854775808);", "(--9223372036854775808)"},
+		{64, "-9223372036854775807;", "(-9223372036854775807)"},
+		{16, "-32768;", "-32768"},
+		{32, "a * -2147483648;", "(a * -2147483648)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.InitializeLexerWithOptions(tt.input, lexer.Options{IntegerWidth: tt.width})
+		p := InitializeParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong program, expected=%q, got=%q", tt.expected, program.String())
+		}
+
+		literal := ast.Expression(nil)
+
+		ast.Inspect(program, func(node ast.Node) bool {
+			if integer, ok := node.(*ast.IntegerLiteral); ok {
+				literal = integer
+			}
+
+			return true
+		})
+
+		if value := literal.(*ast.IntegerLiteral).Value; strings.HasPrefix(literal.TokenLiteral(), "-") && -1<<uint(tt.width-1) != value {
+			t.Errorf("wrong value for %q, got=%d", tt.input, value)
+		}
+	}
+
+	errors := []struct {
+		width    int
+		input    string
+		expected string
+	}{
+		{16, "a := a - 32768;", "1:10: error[L005]: number out of range \"32768\", the largest 16-bit integer is 32767"},
+		{16, "a := (32768);", "1:7: error[L005]: number out of range \"32768\", the largest 16-bit integer is 32767"},
+		{64, "a := a - 9223372036854775808;", "1:10: error[L005]: number out of range \"9223372036854775808\", the largest 64-bit integer is 9223372036854775807"},
+	}
+
+	for _, tt := range errors {
+		p := InitializeParser(lexer.InitializeLexerWithOptions(tt.input, lexer.Options{IntegerWidth: tt.width}))
+		p.ParseProgram()
+		diagnostics := p.Diagnostics()
+
+		if 1 != len(diagnostics) || diagnostics[0].Format() != tt.expected {
+			t.Errorf("wrong diagnostics for %q, expected=%q, got=%q", tt.input, tt.expected, diagnostics.Strings())
+		}
+	}
+}
+
+// TestStringLiteralExpression :
+func TestStringLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedValue string
+	}{
+		{"'hello world'", "hello world"},
+		{"''", ""},
+		{"'it''s'", "it's"},
+		{"''''", "'"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.InitializeLexer("writeln(" + tt.input + ", x)")
+		p := InitializeParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		statement, ok := program.Statements[0].(*ast.WriteStatement)
+
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.WriteStatement, got=%T", program.Statements[0])
+		}
+
+		literal, ok := statement.Arguments[0].(*ast.StringLiteral)
+
+		if !ok {
+			t.Fatalf("argument not *ast.StringLiteral, got=%T", statement.Arguments[0])
+		}
+
+		if literal.Value != tt.expectedValue {
+			t.Errorf("literal.Value wrong, expected=%q, got=%q", tt.expectedValue, literal.Value)
+		}
+
+		if "writeln("+tt.input+", x);" != statement.String() {
+			t.Errorf("statement.String() wrong, got=%q", statement.String())
+		}
+	}
+}
+
+// TestNumberLiteralValues : decimal integers whatever their leading zeros, separators and exponents
+func TestNumberLiteralValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"010", int64(10)},
+		{"0", int64(0)},
+		{"1_000_000", int64(1000000)},
+		{"1.5e-3", 0.0015},
+		{"2E3", 2000.0},
+		{"1_000.5", 1000.5},
+	}
+
+	for _, tt := range tests {
+		l := lexer.InitializeLexer(tt.input)
+		p := InitializeParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		expression := program.Statements[0].(*ast.ExpressionStatement).Expression
+
+		switch expected := tt.expected.(type) {
+		case int64:
+			literal, ok := expression.(*ast.IntegerLiteral)
+
+			if !ok || literal.Value != expected {
+				t.Errorf("wrong value for %q, expected=%d, got=%T (%+v)", tt.input, expected, expression, expression)
+			}
+		case float64:
+			literal, ok := expression.(*ast.RealLiteral)
+
+			if !ok || literal.Value != expected {
+				t.Errorf("wrong value for %q, expected=%f, got=%T (%+v)", tt.input, expected, expression, expression)
+			}
+		}
+
+		if expression.String() != tt.input {
+			t.Errorf("literal not kept as written, expected=%q, got=%q", tt.input, expression.String())
+		}
+	}
+}
+
 // TestRealLiteralExpression :
 func TestRealLiteralExpression(t *testing.T) {
 	input := "5.5;"
@@ -419,6 +582,11 @@ func TestParsingPrefixExpressions(t *testing.T) {
 			"-",
 			15.5,
 		},
+		{
+			"not a",
+			"not",
+			"a",
+		},
 	}
 
 	for _, tt := range prefixTests {
@@ -558,6 +726,48 @@ func TestParsingInfixExpressions(t *testing.T) {
 			"<>",
 			5.5,
 		},
+		{
+			"5 = 5",
+			5,
+			"=",
+			5,
+		},
+		{
+			"5 <= 5",
+			5,
+			"<=",
+			5,
+		},
+		{
+			"5 >= 5",
+			5,
+			">=",
+			5,
+		},
+		{
+			"5 div 5",
+			5,
+			"div",
+			5,
+		},
+		{
+			"5 mod 5",
+			5,
+			"mod",
+			5,
+		},
+		{
+			"5 and 5",
+			5,
+			"and",
+			5,
+		},
+		{
+			"5 or 5",
+			5,
+			"or",
+			5,
+		},
 	}
 
 	for _, tt := range infixTest {
@@ -627,11 +837,43 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		},
 		{
 			"5 > 4 == 3 < 4",
-			"((5 > 4) == (3 < 4))",
+			"(((5 > 4) == 3) < 4)",
 		},
 		{
 			"5 < 0.4 <> 3 > 4",
-			"((5 < 0.4) <> (3 > 4))",
+			"(((5 < 0.4) <> 3) > 4)",
+		},
+		{
+			"(5 > 4) = (3 <= 4)",
+			"((5 > 4) = (3 <= 4))",
+		},
+		{
+			"a + b >= c * d",
+			"((a + b) >= (c * d))",
+		},
+		{
+			"a or b and c",
+			"(a or (b and c))",
+		},
+		{
+			"not a and b",
+			"((not a) and b)",
+		},
+		{
+			"a < b and c",
+			"(a < (b and c))",
+		},
+		{
+			"(a < b) and (c > d) or not e",
+			"(((a < b) and (c > d)) or (not e))",
+		},
+		{
+			"a div b mod c * d + e",
+			"((((a div b) mod c) * d) + e)",
+		},
+		{
+			"a + b div c",
+			"(a + (b div c))",
 		},
 		{
 			"3 + 4 * 5 == 3 * 1 + 4 * 5",
@@ -925,9 +1167,23 @@ func TestCallExporessionParsing(t *testing.T) {
 	testInfixExpression(t, expression.Arguments[2], 4, "+", 5)
 }
 
-// TestProgramParsing
+// TestProgramLiteralParsing :
 func TestProgramLiteralParsing(t *testing.T) {
-	input := `program main;`
+	input := `program main;
+var x: integer := 1;
+const y: real := 2.5;
+procedure show(a: integer);
+	var b: integer := a;
+	begin
+		b + 1;
+	end;
+procedure nothing;
+	begin
+	end;
+begin
+	x + y;
+	show(x);
+end.`
 
 	l := lexer.InitializeLexer(input)
 	p := InitializeParser(l)
@@ -954,11 +1210,159 @@ func TestProgramLiteralParsing(t *testing.T) {
 	if "main" != expression.Name {
 		t.Fatalf("Wrong program name, expected was=%s, got=%s", "main", expression.Name)
 	}
+
+	if 2 != len(expression.Declarations) {
+		t.Fatalf("expression.Declarations does not contain %d declarations, got=%d", 2, len(expression.Declarations))
+	}
+
+	testVarStatements(t, expression.Declarations[0], "x")
+	testConstStatements(t, expression.Declarations[1], "y")
+
+	if 2 != len(expression.Procedures) {
+		t.Fatalf("expression.Procedures does not contain %d procedures, got=%d", 2, len(expression.Procedures))
+	}
+
+	show := expression.Procedures[0]
+
+	if "show" != show.Name || 1 != len(show.Parameters) || 1 != len(show.Declarations) || 1 != len(show.Body.Statements) {
+		t.Errorf("procedure show parsed wrong, got=%q", show.String())
+	}
+
+	nothing := expression.Procedures[1]
+
+	if "nothing" != nothing.Name || 0 != len(nothing.Parameters) || 0 != len(nothing.Body.Statements) {
+		t.Errorf("procedure nothing parsed wrong, got=%q", nothing.String())
+	}
+
+	if 2 != len(expression.Body.Statements) {
+		t.Fatalf("expression.Body does not contain %d statements, got=%d", 2, len(expression.Body.Statements))
+	}
+
+	body, ok := expression.Body.Statements[1].(*ast.ExpressionStatement)
+
+	if !ok {
+		t.Fatalf("expression.Body.Statements[1] is not ExpressionStatement, got=%T", expression.Body.Statements[1])
+	}
+
+	if _, ok := body.Expression.(*ast.CallExpression); !ok {
+		t.Fatalf("body.Expression is not ast.CallExpression, got=%T", body.Expression)
+	}
+}
+
+// TestProgramLiteralErrors :
+func TestProgramLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+	}{
+		{
+			"program main; begin end",
+			[]string{
+				"1:24: Expected next token to be ., got 'EOF' instead",
+			},
+		},
+		{
+			"program main var x: integer := 1; begin x; end.",
+			[]string{
+				"1:14: Expected next token to be ;, got 'VAR' instead",
+			},
+		},
+		{
+			"program main; procedure p(a integer); begin a; end; begin x;",
+			[]string{
+				"1:29: Expected next token to be :, got 'INTEGER_KEYWORD' instead",
+				"1:61: Expected token to be END, got 'EOF' instead",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.InitializeLexer(tt.input)
+		p := InitializeParser(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+
+		if len(errors) != len(tt.expectedErrors) {
+			t.Fatalf("wrong number of errors for %q, expected=%d, got=%d: %q", tt.input, len(tt.expectedErrors), len(errors), errors)
+		}
+
+		for i, message := range tt.expectedErrors {
+			if errors[i] != message {
+				t.Errorf("errors[%d] wrong, expected=%q, got=%q", i, message, errors[i])
+			}
+		}
+	}
 }
 
 // TestWhileLiteral :
 func TestWhileLiteral(t *testing.T) {
-	input := `while (a < 10) do`
+	tests := []struct {
+		input             string
+		expectedCondition string
+		expectedBody      int
+	}{
+		{
+			"while (a < 10) do a := a + 1;",
+			"(a < 10)",
+			1,
+		},
+		{
+			"while a < 10 do begin a := a + 1; b := a end",
+			"(a < 10)",
+			2,
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.InitializeLexer(tt.input)
+		p := InitializeParser(l)
+		program := p.ParseProgram()
+
+		checkParserErrors(t, p)
+
+		if 1 != len(program.Statements) {
+			t.Fatalf("program.Statements does not contain %d statements, got=%d", 1, len(program.Statements))
+		}
+
+		statement, ok := program.Statements[0].(*ast.ExpressionStatement)
+
+		if !ok {
+			t.Fatalf("statement is not ExpressionStatement, got=%T", program.Statements[0])
+		}
+
+		expression, ok := statement.Expression.(*ast.WhileLiteral)
+
+		if !ok {
+			t.Fatalf("statement.Expression is not ast.WhileLiteral, got=%T", statement.Expression)
+		}
+
+		if tt.expectedCondition != expression.Condition.String() {
+			t.Fatalf("expression.Condition.String() is not '%s', got=%s", tt.expectedCondition, expression.Condition.String())
+		}
+
+		switch body := expression.Body.(type) {
+		case *ast.BlockStatement:
+			if tt.expectedBody != len(body.Statements) {
+				t.Fatalf("body does not contain %d statements, got=%d", tt.expectedBody, len(body.Statements))
+			}
+		case *ast.AssignStatement:
+			if 1 != tt.expectedBody {
+				t.Fatalf("body is a single statement, expected=%d", tt.expectedBody)
+			}
+		default:
+			t.Fatalf("expression.Body is not a statement or block, got=%T", expression.Body)
+		}
+	}
+}
+
+// TestForLiteral :
+func TestForLiteral(t *testing.T) {
+	input := `for a := 1 to n * 2 do
+	begin
+		b := b + a;
+		c := a
+	end`
 
 	l := lexer.InitializeLexer(input)
 	p := InitializeParser(l)
@@ -976,20 +1380,40 @@ func TestWhileLiteral(t *testing.T) {
 		t.Fatalf("statement is not ExpressionStatement, got=%T", program.Statements[0])
 	}
 
-	expression, ok := statement.Expression.(*ast.WhileLiteral)
+	expression, ok := statement.Expression.(*ast.ForLiteral)
+
+	if !ok {
+		t.Fatalf("statement.Expression is not ast.ForLiteral, got=%T", statement.Expression)
+	}
+
+	if !testIdentifier(t, expression.Variable, "a") {
+		return
+	}
+
+	if !testLiteralExpresion(t, expression.From, 1) {
+		return
+	}
+
+	if !testInfixExpression(t, expression.To, "n", "*", 2) {
+		return
+	}
+
+	body, ok := expression.Body.(*ast.BlockStatement)
 
 	if !ok {
-		t.Fatalf("statement.Expression is not ast.WhileLiteral, got=%T", statement.Expression)
+		t.Fatalf("expression.Body is not ast.BlockStatement, got=%T", expression.Body)
 	}
 
-	if "(a < 10)" != expression.Condition.String() {
-		t.Fatalf("expression.Condition.String() is not '%s', got=%s", "(a < 10)", expression.Condition.String())
+	if 2 != len(body.Statements) {
+		t.Fatalf("body does not contain %d statements, got=%d", 2, len(body.Statements))
 	}
+
+	testAssignStatement(t, body.Statements[1], "c", "a")
 }
 
-// TestForLiteral :
-func TestForLiteral(t *testing.T) {
-	input := `for a to 10 do`
+// TestNestedLoops :
+func TestNestedLoops(t *testing.T) {
+	input := "for i := 0 to 10 do while i < 5 do i := i + 1; x := i"
 
 	l := lexer.InitializeLexer(input)
 	p := InitializeParser(l)
@@ -997,34 +1421,266 @@ func TestForLiteral(t *testing.T) {
 
 	checkParserErrors(t, p)
 
+	if 2 != len(program.Statements) {
+		t.Fatalf("program.Statements does not contain %d statements, got=%d", 2, len(program.Statements))
+	}
+
+	expected := "for i := 0 to 10 do while (i < 5) do i := (i + 1);"
+
+	if expected != program.Statements[0].String() {
+		t.Errorf("expected=%q, got=%q", expected, program.Statements[0].String())
+	}
+
+	testAssignStatement(t, program.Statements[1], "x", "i")
+}
+
+// TestComments : comments never reach the parser
+func TestComments(t *testing.T) {
+	input := `{just a simple comment}
+var x: integer {the counter} := 1; {done}`
+
+	l := lexer.InitializeLexerWithOptions(input, lexer.Options{KeepComments: true})
+	p := InitializeParser(l)
+	program := p.ParseProgram()
+
+	checkParserErrors(t, p)
+
 	if 1 != len(program.Statements) {
 		t.Fatalf("program.Statements does not contain %d statements, got=%d", 1, len(program.Statements))
 	}
 
-	statement, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if "var x: integer := 1;" != program.Statements[0].String() {
+		t.Fatalf("expected=%q, got=%q", "var x: integer := 1;", program.Statements[0].String())
+	}
 
-	if !ok {
-		t.Fatalf("statement is not ExpressionStatement, got=%T", program.Statements[0])
+	if 3 != len(l.Comments()) {
+		t.Fatalf("wrong number of comments, expected=%d, got=%d", 3, len(l.Comments()))
 	}
+}
 
-	expression, ok := statement.Expression.(*ast.ForLiteral)
+// TestErrorPosition :
+func TestErrorPosition(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{
+			"var x integer := 5;",
+			"1:7: Expected next token to be :, got 'INTEGER_KEYWORD' instead",
+		},
+		{
+			"var x: integer := 5;\nvar y: real := ;",
+			"2:16: no prefix parse function for ';' was found",
+		},
+		{
+			"\n\n  99999999999999999999;",
+			"3:3: number out of range \"99999999999999999999\", the largest 64-bit integer is 9223372036854775807",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.InitializeLexer(tt.input)
+		p := InitializeParser(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+
+		if 0 == len(errors) {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error message, expected=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+// TestStatementTerminators : only the last statement of a block or of the input may omit its semicolon
+func TestStatementTerminators(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+		statements     int
+	}{
+		{"t := 2 writeln(t) t := 3", []string{
+			"1:8: Expected next token to be ;, got 'WRITELN' instead",
+			"1:19: Expected next token to be ;, got 'IDENTIFIER' instead",
+		}, 3},
+		{"read(a) a + 1", []string{"1:9: Expected next token to be ;, got 'IDENTIFIER' instead"}, 2},
+		{"if a then b := 1 end writeln", []string{"1:22: Expected next token to be ;, got 'WRITELN' instead"}, 2},
+		{"while a do b := 1 writeln", []string{"1:19: Expected next token to be ;, got 'WRITELN' instead"}, 2},
+		{"while a do begin b := 1 end writeln", []string{"1:29: Expected next token to be ;, got 'WRITELN' instead"}, 2},
+		{"if a then b := 1; c := 2 end; while a do b := 1; for i := 1 to 2 do writeln(i); writeln", []string{}, 4},
+		{"procedure p; begin writeln end; p()", []string{}, 2},
+		{"begin a := 1; begin writeln end end; begin end writeln", []string{"1:48: Expected next token to be ;, got 'WRITELN' instead"}, 3},
+	}
+
+	for _, tt := range tests {
+		p := InitializeParser(lexer.InitializeLexer(tt.input))
+		program := p.ParseProgram()
+
+		if strings.Join(p.Errors(), "\n") != strings.Join(tt.expectedErrors, "\n") {
+			t.Errorf("wrong errors for %q, expected=%q, got=%q", tt.input, tt.expectedErrors, p.Errors())
+		}
+
+		if len(program.Statements) != tt.statements {
+			t.Errorf("wrong number of statements for %q, expected=%d, got=%d", tt.input, tt.statements, len(program.Statements))
+		}
+	}
+}
+
+// TestPanicModeRecovery :
+func TestPanicModeRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements int
+	}{
+		{
+			"var bar := 2;",
+			[]string{
+				"1:9: Expected next token to be :, got ':=' instead",
+			},
+			0,
+		},
+		{
+			"var x integer := 1; var y: integer := 2; const z: real 3; 5 + 5;",
+			[]string{
+				"1:7: Expected next token to be :, got 'INTEGER_KEYWORD' instead",
+				"1:56: Expected next token to be :=, got 'INTEGER' instead",
+			},
+			2,
+		},
+		{
+			"if x < then var a: integer := ; a + 1; end",
+			[]string{
+				"1:8: no prefix parse function for 'THEN' was found",
+				"1:31: no prefix parse function for ';' was found",
+			},
+			1,
+		},
+		{
+			"procedure add(x integer, y: real); begin x + * y; end 1 + 1",
+			[]string{
+				"1:17: Expected next token to be :, got 'INTEGER_KEYWORD' instead",
+				"1:46: no prefix parse function for '*' was found",
+			},
+			2,
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.InitializeLexer(tt.input)
+		p := InitializeParser(l)
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+
+		if len(errors) != len(tt.expectedErrors) {
+			t.Fatalf("wrong number of errors for %q, expected=%d, got=%d: %q", tt.input, len(tt.expectedErrors), len(errors), errors)
+		}
+
+		for i, message := range tt.expectedErrors {
+			if errors[i] != message {
+				t.Errorf("errors[%d] wrong, expected=%q, got=%q", i, message, errors[i])
+			}
+		}
+
+		if len(program.Statements) != tt.expectedStatements {
+			t.Errorf("wrong number of statements for %q, expected=%d, got=%d", tt.input, tt.expectedStatements, len(program.Statements))
+		}
+	}
+}
+
+// TestDiagnostics :
+func TestDiagnostics(t *testing.T) {
+	input := "var x: boolean := 1;\nvar y: integer := @;"
+
+	l := lexer.InitializeLexer(input)
+	p := InitializeParser(l)
+	p.ParseProgram()
+
+	diagnostics := p.Diagnostics()
+
+	if 2 != len(diagnostics) {
+		t.Fatalf("wrong number of diagnostics, expected=%d, got=%d: %q", 2, len(diagnostics), diagnostics.Strings())
+	}
+
+	unexpected := diagnostics[0]
+
+	if diagnostic.UNEXPECTED_TOKEN != unexpected.Code || diagnostic.ERROR != unexpected.Severity {
+		t.Errorf("diagnostics[0] wrong kind, got=%s", unexpected.Format())
+	}
+
+	if 2 != len(unexpected.Expected) || token.INTEGER_KEYWORD != unexpected.Expected[0] || token.REAL_KEYWORD != unexpected.Expected[1] {
+		t.Errorf("diagnostics[0].Expected wrong, got=%q", unexpected.Expected)
+	}
+
+	if "boolean" != unexpected.Found.Literal || 8 != unexpected.Start.Column || 15 != unexpected.End.Column {
+		t.Errorf("diagnostics[0] span wrong, got=%s-%s found=%q", unexpected.Start, unexpected.End, unexpected.Found.Literal)
+	}
+
+	if "1:8: Expected next token to be INTEGER_KEYWORD or REAL_KEYWORD, got 'IDENTIFIER' instead" != unexpected.String() {
+		t.Errorf("diagnostics[0].String() wrong, got=%q", unexpected.String())
+	}
+
+	if diagnostic.INVALID_CHARACTER != diagnostics[1].Code || 2 != diagnostics[1].Start.Line {
+		t.Errorf("diagnostics[1] wrong, got=%s", diagnostics[1].Format())
+	}
+}
+
+// testAssignStatement :
+func testAssignStatement(t *testing.T, s ast.Statement, name string, value interface{}) bool {
+	assignStatement, ok := s.(*ast.AssignStatement)
 
 	if !ok {
-		t.Fatalf("statement.Expression is not ast.ForLiteral, got=%T", statement.Expression)
+		t.Errorf("s not *ast.AssignStatement, got=%T", s)
+
+		return false
 	}
 
-	if "a" != expression.Variable {
-		t.Fatalf("expression.Variable is not '%s', got=%s", "a", expression.Variable)
+	if ":=" != assignStatement.TokenLiteral() {
+		t.Errorf("assignStatement.TokenLiteral not ':=', got=%q", assignStatement.TokenLiteral())
+
+		return false
 	}
 
-	if "10" != expression.Desired {
-		t.Fatalf("expression.Desired is not '%s', got=%s", "10", expression.Desired)
+	if !testIdentifier(t, assignStatement.Name, name) {
+		return false
 	}
+
+	return testLiteralExpresion(t, assignStatement.Value, value)
 }
 
-// TestCommentLiteral :
-func TestCommentLiteral(t *testing.T) {
-	input := `{just a simple comment}`
+// TestAssignStatements :
+func TestAssignStatements(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedName  string
+		expectedValue interface{}
+	}{
+		{"foo := 5;", "foo", 5},
+		{"foo := 5.5", "foo", 5.5},
+		{"foo := bar;", "foo", "bar"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.InitializeLexer(tt.input)
+		p := InitializeParser(l)
+		program := p.ParseProgram()
+
+		checkParserErrors(t, p)
+
+		if 1 != len(program.Statements) {
+			t.Fatalf("program.Statements does not contain %d statements, got=%d", 1, len(program.Statements))
+		}
+
+		if !testAssignStatement(t, program.Statements[0], tt.expectedName, tt.expectedValue) {
+			return
+		}
+	}
+
+	input := "x := 1 + 2 * y;"
 
 	l := lexer.InitializeLexer(input)
 	p := InitializeParser(l)
@@ -1032,23 +1688,169 @@ func TestCommentLiteral(t *testing.T) {
 
 	checkParserErrors(t, p)
 
-	if 1 != len(program.Statements) {
-		t.Fatalf("program.Statements does not contain %d statements, got=%d", 1, len(program.Statements))
+	if "x := (1 + (2 * y));" != program.String() {
+		t.Errorf("program.String() wrong, got=%q", program.String())
 	}
+}
 
-	statement, ok := program.Statements[0].(*ast.ExpressionStatement)
+// TestNestedAssignStatements :
+func TestNestedAssignStatements(t *testing.T) {
+	input := `program main;
+procedure p(a: integer);
+	begin
+		a := 1;
+		b := a
+	end;
+begin
+	if a < b then a := b; b := 2 end else a := 3 end
+end.`
 
-	if !ok {
-		t.Fatalf("statement is not ExpressionStatement, got=%T", program.Statements[0])
+	l := lexer.InitializeLexer(input)
+	p := InitializeParser(l)
+	program := p.ParseProgram()
+
+	checkParserErrors(t, p)
+
+	literal := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.ProgramLiteral)
+	body := literal.Procedures[0].Body
+
+	if 2 != len(body.Statements) {
+		t.Fatalf("procedure body does not contain %d statements, got=%d", 2, len(body.Statements))
 	}
 
-	expression, ok := statement.Expression.(*ast.CommentLiteral)
+	testAssignStatement(t, body.Statements[0], "a", 1)
+	testAssignStatement(t, body.Statements[1], "b", "a")
 
-	if !ok {
-		t.Fatalf("statement.Expression is not ast.CommentLiteral, got=%T", statement.Expression)
+	conditional := literal.Body.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.ConditionalExpression)
+
+	if 2 != len(conditional.Consequence.Statements) {
+		t.Fatalf("consequence does not contain %d statements, got=%d", 2, len(conditional.Consequence.Statements))
+	}
+
+	testAssignStatement(t, conditional.Consequence.Statements[0], "a", "b")
+	testAssignStatement(t, conditional.Consequence.Statements[1], "b", 2)
+	testAssignStatement(t, conditional.Alternative.Statements[0], "a", 3)
+}
+
+// TestInputOutputStatements :
+func TestInputOutputStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"read(a);", "read(a);"},
+		{"read(a, b, c)", "read(a, b, c);"},
+		{"readln;", "readln();"},
+		{"readln(x);", "readln(x);"},
+		{"write(a + 1, b * 2);", "write((a + 1), (b * 2));"},
+		{"writeln", "writeln();"},
+		{"writeln();", "writeln();"},
+		{"writeln(f(a), -b)", "writeln(f(a), (-b));"},
 	}
 
-	if "{ just a simple comment }" != expression.String() {
-		t.Fatalf("expression.String() is not '%s', got=%s", "{ just a simple comment }", expression.String())
+	for _, tt := range tests {
+		l := lexer.InitializeLexer(tt.input)
+		p := InitializeParser(l)
+		program := p.ParseProgram()
+
+		checkParserErrors(t, p)
+
+		if 1 != len(program.Statements) {
+			t.Fatalf("program.Statements does not contain %d statements, got=%d", 1, len(program.Statements))
+		}
+
+		switch program.Statements[0].(type) {
+		case *ast.ReadStatement, *ast.WriteStatement:
+		default:
+			t.Fatalf("program.Statements[0] is not an I/O statement, got=%T", program.Statements[0])
+		}
+
+		if tt.expected != program.String() {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+// TestInputOutputErrors :
+func TestInputOutputErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedCode  diagnostic.Code
+		expectedError string
+	}{
+		{
+			"read(a, 1 + b); x := 1;",
+			diagnostic.INVALID_READ_TARGET,
+			"1:9: cannot read into '(1 + b)', expected a variable",
+		},
+		{
+			"readln(f(x)); x := 1;",
+			diagnostic.INVALID_READ_TARGET,
+			"1:8: cannot readln into 'f(x)', expected a variable",
+		},
+		{
+			"write(); x := 1;",
+			diagnostic.MISSING_ARGUMENTS,
+			"1:7: write expects at least one argument",
+		},
+		{
+			"read a; x := 1;",
+			diagnostic.UNEXPECTED_TOKEN,
+			"1:6: Expected next token to be (, got 'IDENTIFIER' instead",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.InitializeLexer(tt.input)
+		p := InitializeParser(l)
+		program := p.ParseProgram()
+
+		diagnostics := p.Diagnostics()
+
+		if 1 != len(diagnostics) {
+			t.Fatalf("wrong number of diagnostics for %q, expected=%d, got=%d: %q", tt.input, 1, len(diagnostics), diagnostics.Strings())
+		}
+
+		if tt.expectedCode != diagnostics[0].Code || tt.expectedError != diagnostics[0].String() {
+			t.Errorf("wrong diagnostic, expected=%s %q, got=%s", tt.expectedCode, tt.expectedError, diagnostics[0].Format())
+		}
+
+		if 1 != len(program.Statements) {
+			t.Fatalf("the statement after the error was not recovered, got=%d statements", len(program.Statements))
+		}
+	}
+
+	p := InitializeParser(lexer.InitializeLexer("read(a, 1 + b);"))
+	p.ParseProgram()
+
+	if target := p.Diagnostics()[0]; "1:9" != target.Start.String() || "1:14" != target.End.String() {
+		t.Errorf("the invalid target should be underlined, expected=1:9-1:14, got=%s-%s", target.Start, target.End)
+	}
+}
+
+// benchmarkProgram : a few megabytes of procedures, each one parsed into its own statement
+var benchmarkProgram = strings.Repeat(`procedure accumulate(step: integer, limit: real);
+	var total: real := 0;
+	begin
+		while total <= limit do
+			begin
+				if (total > 10) and not (step = 0) then total := total + step * 2 end
+				else total := total + 1.5 end;
+				writeln(total div 2, total mod 3)
+			end
+	end;
+`, 20000)
+
+// BenchmarkParser : lexing included, run with -benchmem
+func BenchmarkParser(b *testing.B) {
+	b.SetBytes(int64(len(benchmarkProgram)))
+
+	for i := 0; i < b.N; i++ {
+		p := InitializeParser(lexer.InitializeLexer(benchmarkProgram))
+		p.ParseProgram()
+
+		if 0 != len(p.Diagnostics()) {
+			b.Fatalf("parser errors: %q", p.Errors()[0])
+		}
 	}
 }
@@ -0,0 +1,377 @@
+package printer
+
+import (
+	"bytes"
+	"math"
+	"strings"
+
+	"../ast"
+	"../diagnostic"
+	"../lexer"
+	"../parser"
+	"../token"
+)
+
+// INDENTATION : one level of the block structure
+const INDENTATION = "\t"
+
+// Printer : writes a syntax tree back as LALG source in the canonical layout, one statement per line indented with
+// tabs, keywords in lower case and only the parentheses the precedence of the operators requires
+type Printer struct {
+	out   bytes.Buffer
+	depth int
+	// Nothing was written on the current line yet, not even its indentation
+	empty bool
+	// Comments still to be printed, in source order
+	comments []token.Token
+	// Source line of what was printed last, telling which comments trail it and where the source had blank lines
+	line int
+	// Whatever is printed next starts a block, so no blank line goes before it
+	first bool
+	// Whatever is printed next starts a section, so a blank line goes before it
+	blank bool
+}
+
+// InitializePrinter : the comments, as collected by the lexer, are printed where they were found between the
+// statements; those found inside a statement are moved after it
+func InitializePrinter(comments []token.Token) *Printer {
+	return &Printer{
+		comments: append([]token.Token{}, comments...),
+		first:    true,
+	}
+}
+
+// write : text added to the current line, which is indented first
+func (p *Printer) write(texts ...string) {
+	if p.empty {
+		p.out.WriteString(strings.Repeat(INDENTATION, p.depth))
+		p.empty = false
+	}
+
+	for _, text := range texts {
+		p.out.WriteString(text)
+	}
+}
+
+// startLine : every item goes on a line of its own, after a blank line when the source had one or a section starts
+func (p *Printer) startLine(line int) {
+	if 0 != p.out.Len() {
+		p.out.WriteString("\n")
+
+		if p.blank || (!p.first && line > p.line+1) {
+			p.out.WriteString("\n")
+		}
+	}
+
+	p.empty = true
+	p.first = false
+	p.blank = false
+	p.line = line
+}
+
+// leading : comments that come before the offset, those on the line printed last trailing it
+func (p *Printer) leading(offset int) {
+	for 0 != len(p.comments) && p.comments[0].Position.Offset < offset {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+
+		if comment.Position.Line == p.line && 0 != p.out.Len() {
+			p.write(" ")
+		} else {
+			p.startLine(comment.Position.Line)
+		}
+
+		p.write(comment.Literal)
+		p.line = comment.Position.Line + strings.Count(comment.Literal, "\n")
+	}
+}
+
+// item : starts the line of a node, after the comments that come before it
+func (p *Printer) item(node ast.Node) {
+	p.leading(node.Pos().Offset)
+	p.startLine(node.Pos().Line)
+}
+
+// closed : statements that print their own terminator
+func closed(statement ast.Statement) bool {
+	switch statement := statement.(type) {
+	case *ast.VarStatement, *ast.ConstStatement:
+		return true
+	case *ast.ExpressionStatement:
+		switch statement.Expression.(type) {
+		case *ast.ProcedureLiteral, *ast.ProgramLiteral:
+			return true
+		}
+	}
+
+	return false
+}
+
+// statements : one per line, terminated by semicolons or only separated by them as in the blocks
+func (p *Printer) statements(statements []ast.Statement, terminated bool) {
+	for i, statement := range statements {
+		p.item(statement)
+		p.statement(statement)
+
+		if !closed(statement) && (terminated || i < len(statements)-1) {
+			p.write(";")
+		}
+
+		p.line = statement.End().Line
+	}
+}
+
+// block : the statements of the block, indented, and the "end" closing it; the opening keyword is already printed
+func (p *Printer) block(block *ast.BlockStatement) {
+	p.line = block.Token.Position.Line
+	p.first = true
+	p.depth++
+	p.statements(block.Statements, false)
+	p.leading(block.EndToken.Position.Offset)
+	p.depth--
+
+	p.first = true
+	p.startLine(block.EndToken.Position.Line)
+	p.write("end")
+}
+
+// body : body of the loops, either a block starting on the line of the loop or a statement indented on the next one
+func (p *Printer) body(body ast.Statement) {
+	if block, ok := body.(*ast.BlockStatement); ok {
+		p.write(" begin")
+		p.block(block)
+
+		return
+	}
+
+	p.first = true
+	p.depth++
+	p.item(body)
+	p.statement(body)
+	p.depth--
+}
+
+// statement :
+func (p *Printer) statement(statement ast.Statement) {
+	switch node := statement.(type) {
+	case *ast.VarStatement:
+		p.write("var ", node.Name.Value, ": ", node.Type.Literal)
+
+		if nil != node.Value {
+			p.write(" := ")
+			p.expression(node.Value, parser.LOWEST)
+		}
+
+		p.write(";")
+	case *ast.ConstStatement:
+		p.write("const ", node.Name.Value, ": ", node.Type.Literal, " := ")
+		p.expression(node.Value, parser.LOWEST)
+		p.write(";")
+	case *ast.AssignStatement:
+		p.write(node.Name.Value, " := ")
+		p.expression(node.Value, parser.LOWEST)
+	case *ast.ReadStatement:
+		p.write(node.Token.Literal)
+
+		if 0 != len(node.Arguments) {
+			arguments := []ast.Expression{}
+
+			for _, argument := range node.Arguments {
+				arguments = append(arguments, argument)
+			}
+
+			p.arguments(arguments)
+		}
+	case *ast.WriteStatement:
+		p.write(node.Token.Literal)
+
+		if 0 != len(node.Arguments) {
+			p.arguments(node.Arguments)
+		}
+	case *ast.ExpressionStatement:
+		p.expression(node.Expression, parser.LOWEST)
+	case *ast.BlockStatement:
+		p.write("begin")
+		p.block(node)
+	}
+}
+
+// arguments : "(a, b + 1)"
+func (p *Printer) arguments(arguments []ast.Expression) {
+	p.write("(")
+
+	for i, argument := range arguments {
+		if 0 != i {
+			p.write(", ")
+		}
+
+		p.expression(argument, parser.LOWEST)
+	}
+
+	p.write(")")
+}
+
+// expression : parenthesized only when its operator binds looser than the given precedence
+func (p *Printer) expression(expression ast.Expression, precedence int) {
+	switch node := expression.(type) {
+	case *ast.Identifier:
+		p.write(node.Value)
+	case *ast.IntegerLiteral, *ast.RealLiteral, *ast.StringLiteral:
+		// kept as written, digit separators and doubled quotes included
+		p.write(node.TokenLiteral())
+	case *ast.PrefixExpression:
+		grouped := parser.PREFIX < precedence
+
+		if grouped {
+			p.write("(")
+		}
+
+		p.write(node.Operator)
+
+		// keeps word operators apart from their operand, and a minus from a negative one, "--x" reading as a decrement
+		if token.NOT == node.Token.Type || negative(node.Right) {
+			p.write(" ")
+		}
+
+		p.expression(node.Right, parser.PREFIX)
+
+		if grouped {
+			p.write(")")
+		}
+	case *ast.InfixExpression:
+		operator := parser.Precedence(node.Token.Type)
+		grouped := operator < precedence
+
+		if grouped {
+			p.write("(")
+		}
+
+		// operators associate to the left, so a right operand as loose as the operator needs parentheses
+		p.expression(node.Left, operator)
+		p.write(" ", node.Operator, " ")
+		p.expression(node.Right, operator+1)
+
+		if grouped {
+			p.write(")")
+		}
+	case *ast.CallExpression:
+		p.expression(node.Procedure, parser.CALL)
+		p.arguments(node.Arguments)
+	case *ast.ConditionalExpression:
+		p.write("if ")
+		p.expression(node.Condition, parser.LOWEST)
+		p.write(" then")
+		p.block(node.Consequence)
+
+		if nil != node.Alternative {
+			p.write(" else")
+			p.block(node.Alternative)
+		}
+	case *ast.WhileLiteral:
+		p.write("while ")
+		p.expression(node.Condition, parser.LOWEST)
+		p.write(" do")
+		p.body(node.Body)
+	case *ast.ForLiteral:
+		p.write("for ", node.Variable.Value, " := ")
+		p.expression(node.From, parser.LOWEST)
+		p.write(" to ")
+		p.expression(node.To, parser.LOWEST)
+		p.write(" do")
+		p.body(node.Body)
+	case *ast.ProcedureLiteral:
+		p.procedure(node)
+	case *ast.ProgramLiteral:
+		p.program(node)
+	}
+}
+
+// negative : whether the expression is printed starting with a minus sign
+func negative(expression ast.Expression) bool {
+	switch node := expression.(type) {
+	case *ast.PrefixExpression:
+		return token.MINUS == node.Token.Type
+	case *ast.IntegerLiteral:
+		return strings.HasPrefix(node.TokenLiteral(), "-")
+	}
+
+	return false
+}
+
+// procedure : "procedure name(a: integer, b: real);" with the declarations below it, both at the level of the header
+func (p *Printer) procedure(node *ast.ProcedureLiteral) {
+	p.write("procedure ", node.Name)
+
+	if 0 != len(node.Parameters) {
+		p.write("(")
+
+		for i, parameter := range node.Parameters {
+			if 0 != i {
+				p.write(", ")
+			}
+
+			p.write(parameter.Value, ": ", parameter.Type.Literal)
+		}
+
+		p.write(")")
+	}
+
+	p.write(";")
+
+	p.first = true
+	p.statements(node.Declarations, true)
+
+	p.item(node.Body)
+	p.write("begin")
+	p.block(node.Body)
+	p.write(";")
+}
+
+// program : the declarations, every procedure and the main body are set apart by blank lines
+func (p *Printer) program(node *ast.ProgramLiteral) {
+	p.write("program ", node.Name, ";")
+
+	if 0 != len(node.Declarations) {
+		p.blank = true
+		p.statements(node.Declarations, true)
+	}
+
+	for _, procedure := range node.Procedures {
+		p.blank = true
+		p.item(procedure)
+		p.procedure(procedure)
+		p.line = procedure.End().Line
+	}
+
+	p.blank = true
+	p.item(node.Body)
+	p.write("begin")
+	p.block(node.Body)
+	p.write(".")
+}
+
+// Print : source of the program, ending with a new line unless it is empty
+func (p *Printer) Print(program *ast.Program) string {
+	p.statements(program.Statements, true)
+	p.leading(math.MaxInt)
+
+	if 0 != p.out.Len() {
+		p.out.WriteString("\n")
+	}
+
+	return p.out.String()
+}
+
+// Format : canonical source of what the lexer reads, nothing being printed when it has syntax errors; comments are
+// only printed when the options of the lexer keep them
+func Format(l *lexer.Lexer) (string, diagnostic.List) {
+	p := parser.InitializeParser(l)
+	program := p.ParseProgram()
+	diagnostics := p.Diagnostics()
+
+	if diagnostics.HasErrors() {
+		return "", diagnostics
+	}
+
+	return InitializePrinter(l.Comments()).Print(program), diagnostics
+}
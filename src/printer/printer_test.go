@@ -0,0 +1,299 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"../ast"
+	"../lexer"
+	"../parser"
+)
+
+// testLexer : lexer keeping the comments, as the printer needs them
+func testLexer(input string) *lexer.Lexer {
+	options := lexer.DefaultOptions()
+	options.KeepComments = true
+
+	return lexer.InitializeLexerWithOptions(input, options)
+}
+
+// testFormat :
+func testFormat(t *testing.T, input string) string {
+	output, diagnostics := Format(testLexer(input))
+
+	if diagnostics.HasErrors() {
+		t.Fatalf("format errors for %q: %q", input, diagnostics.Strings())
+	}
+
+	return output
+}
+
+// shape : every node of the tree with what tells it apart, positions left out
+func shape(t *testing.T, input string) string {
+	p := parser.InitializeParser(lexer.InitializeLexer(input))
+	program := p.ParseProgram()
+
+	if 0 != len(p.Errors()) {
+		t.Fatalf("parser errors for %q: %q", input, p.Errors())
+	}
+
+	nodes := []string{}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case nil:
+			nodes = append(nodes, ")")
+		case *ast.Identifier:
+			nodes = append(nodes, fmt.Sprintf("(Identifier %s %s", node.Value, node.Type.Literal))
+		case *ast.IntegerLiteral:
+			nodes = append(nodes, fmt.Sprintf("(IntegerLiteral %d", node.Value))
+		case *ast.RealLiteral:
+			nodes = append(nodes, fmt.Sprintf("(RealLiteral %g", node.Value))
+		case *ast.StringLiteral:
+			nodes = append(nodes, fmt.Sprintf("(StringLiteral %q", node.Value))
+		case *ast.PrefixExpression:
+			nodes = append(nodes, "(PrefixExpression "+node.Operator)
+		case *ast.InfixExpression:
+			nodes = append(nodes, "(InfixExpression "+node.Operator)
+		case *ast.VarStatement:
+			nodes = append(nodes, "(VarStatement "+node.Type.Literal)
+		case *ast.ConstStatement:
+			nodes = append(nodes, "(ConstStatement "+node.Type.Literal)
+		case *ast.ProcedureLiteral:
+			nodes = append(nodes, "(ProcedureLiteral "+node.Name)
+		case *ast.ProgramLiteral:
+			nodes = append(nodes, "(ProgramLiteral "+node.Name)
+		default:
+			nodes = append(nodes, fmt.Sprintf("(%T %s", node, node.TokenLiteral()))
+		}
+
+		return true
+	})
+
+	return strings.Join(nodes, " ")
+}
+
+func TestExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a := ((b));", "a := b;\n"},
+		{"a := (b + c) + d;", "a := b + c + d;\n"},
+		{"a := b + (c + d);", "a := b + (c + d);\n"},
+		{"a := b - (c - d) * e;", "a := b - (c - d) * e;\n"},
+		{"a := (b * c) div (d mod e);", "a := b * c div (d mod e);\n"},
+		{"a := -(b) * -(c + 1);", "a := -b * -(c + 1);\n"},
+		{"a := - -b;", "a := - -b;\n"},
+		{"a := -(-b) - (-(-9223372036854775808));", "a := - -b - - -9223372036854775808;\n"},
+		{"write(not (not b), not (b = c), (not b) = c);", "write(not not b, not (b = c), not b = c);\n"},
+		{"write((a < 2) and not (b > 10) or (a = 1));", "write((a < 2) and not (b > 10) or (a = 1));\n"},
+		{"write((a = b) = (c < d), a or (b and c), (a or b) and c);", "write(a = b = (c < d), a or b and c, (a or b) and c);\n"},
+		{"write(1_000, 1.5E3, 'it''s', '');", "write(1_000, 1.5E3, 'it''s', '');\n"},
+		{"p (a,b+1) ; q();", "p(a, b + 1);\nq();\n"},
+	}
+
+	for _, tt := range tests {
+		if output := testFormat(t, tt.input); output != tt.expected {
+			t.Errorf("wrong output for %q, expected=%q, got=%q", tt.input, tt.expected, output)
+		}
+	}
+}
+
+func TestStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"var a:integer;const b : real:=2.5;a:=1", "var a: integer;\nconst b: real := 2.5;\na := 1;\n"},
+		{"readln; readln(); read(a,b); writeln(); write(a)", "readln;\nreadln;\nread(a, b);\nwriteln;\nwrite(a);\n"},
+		{
+			"if a then b := 1; c := 2 end else end",
+			"if a then\n\tb := 1;\n\tc := 2\nend else\nend;\n",
+		},
+		{
+			"while a < 1 do a := a + 1; for i := 1 to n do begin write(i); end",
+			"while a < 1 do\n\ta := a + 1;\nfor i := 1 to n do begin\n\twrite(i)\nend;\n",
+		},
+		{
+			"procedure p; begin end; procedure q(a: integer, b: real); var c: integer; begin c := a end",
+			"procedure p;\nbegin\nend;\nprocedure q(a: integer, b: real);\nvar c: integer;\nbegin\n\tc := a\nend;\n",
+		},
+		{
+			"program p; begin if a then while b do if c then end end end.",
+			"program p;\n\nbegin\n\tif a then\n\t\twhile b do\n\t\t\tif c then\n\t\t\tend\n\tend\nend.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if output := testFormat(t, tt.input); output != tt.expected {
+			t.Errorf("wrong output for %q, expected=\n%s\ngot=\n%s", tt.input, tt.expected, output)
+		}
+	}
+}
+
+func TestProgram(t *testing.T) {
+	input := `program   example ;
+  var x : integer;
+
+
+  var y : real := 1;
+procedure p(a : integer);
+var b : integer;
+
+begin
+b := a * 2;
+
+if b > 2 then write(b) end
+end;
+begin read(x);
+while x > 0 do begin p(x); x := x - 1 end;
+writeln(y / 2) end.`
+
+	expected := `program example;
+
+var x: integer;
+
+var y: real := 1;
+
+procedure p(a: integer);
+var b: integer;
+
+begin
+	b := a * 2;
+
+	if b > 2 then
+		write(b)
+	end
+end;
+
+begin
+	read(x);
+	while x > 0 do begin
+		p(x);
+		x := x - 1
+	end;
+	writeln(y / 2)
+end.
+`
+
+	if output := testFormat(t, input); output != expected {
+		t.Errorf("wrong output, expected=\n%s\ngot=\n%s", expected, output)
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `{ header }
+program comments; { trailing the header }
+var a: integer; { trailing a }
+{ before b }
+
+var b: integer;
+begin { trailing begin }
+	a := {inside} 1;
+	{ before the loop
+	  spanning lines }
+	while a < b do
+		{ before the body }
+		a := a + 1;
+	if a > 1 then
+		{ alone in the block }
+	end
+	{ before end }
+end. { after the dot }
+{ last }`
+
+	expected := `{ header }
+program comments; { trailing the header }
+
+var a: integer; { trailing a }
+{ before b }
+
+var b: integer;
+
+begin { trailing begin }
+	a := 1; {inside}
+	{ before the loop
+	  spanning lines }
+	while a < b do
+		{ before the body }
+		a := a + 1;
+	if a > 1 then
+		{ alone in the block }
+	end
+	{ before end }
+end. { after the dot }
+{ last }
+`
+
+	if output := testFormat(t, input); output != expected {
+		t.Errorf("wrong output, expected=\n%s\ngot=\n%s", expected, output)
+	}
+}
+
+// TestRoundTrip : the output parses back to the same tree and is left as it is when formatted again
+func TestRoundTrip(t *testing.T) {
+	tests := []string{
+		`program walk;
+var a: integer := 1;
+const b: real := 2.5;
+procedure p(x: integer, y: real);
+	var z: integer;
+	begin
+		read(z);
+		if x > z then writeln('big', -y) end else a := z end
+	end;
+begin
+	for a := 1 to 3 do p(a, b);
+	while not (a = 0) do a := a - 1
+end.`,
+		`program recursion;
+var n: integer;
+var result: integer := 1;
+procedure factorial(k: integer);
+	begin
+		if k > 1 then
+			result := result * k;
+			factorial(k - 1)
+		end
+	end;
+begin
+	read(n); factorial(n); writeln(result)
+end.`,
+		"var x: real := 1.5e-3; { c } writeln('x = ', x, ', it''s ', -x > 1 * (2 + 3) mod 4);",
+		"if (a = 3) and not (b > 10) then writeln(a * b, a / 2, 7 div 2, -7 mod 3) end",
+		"a := ((1 - 2) - (3 - 4)) / -(5 * (6 / 7)) - not (not (a or b and c))",
+		"a := -(-b) * -(-(-c)) - (-(-9223372036854775808))",
+	}
+
+	for _, input := range tests {
+		output := testFormat(t, input)
+
+		if shape(t, output) != shape(t, input) {
+			t.Errorf("output of %q parses to a different tree, got=\n%s\nexpected=\n%s", input, shape(t, output), shape(t, input))
+		}
+
+		if again := testFormat(t, output); again != output {
+			t.Errorf("formatting is not idempotent for %q, expected=\n%s\ngot=\n%s", input, output, again)
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	output, diagnostics := Format(testLexer("var a integer; { open"))
+
+	if "" != output {
+		t.Errorf("nothing should be printed, got=%q", output)
+	}
+
+	expected := []string{
+		"1:7: Expected next token to be :, got 'INTEGER_KEYWORD' instead",
+		"1:16: unterminated comment",
+	}
+
+	if strings.Join(diagnostics.Strings(), "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong diagnostics, expected=%q, got=%q", expected, diagnostics.Strings())
+	}
+}
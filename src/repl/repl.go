@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 
+	"../evaluator"
 	"../lexer"
+	"../object"
 	"../parser"
 )
 
@@ -19,20 +21,21 @@ func printParseErrors(out io.Writer, errors []string) {
 	}
 }
 
-// Start :
+// Start : every line is evaluated in the same environment, read statements take their input from the same reader
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+	reader := bufio.NewReader(in)
+	e := evaluator.InitializeEvaluator(reader, out)
+	env := object.InitializeEnvironment()
 
 	for {
-		fmt.Printf(PROMPT)
+		fmt.Fprint(out, PROMPT)
 
-		scanned := scanner.Scan()
+		line, err := reader.ReadString('\n')
 
-		if !scanned {
+		if nil != err && "" == line {
 			return
 		}
 
-		line := scanner.Text()
 		l := lexer.InitializeLexer(line)
 		p := parser.InitializeParser(l)
 		program := p.ParseProgram()
@@ -43,8 +46,10 @@ func Start(in io.Reader, out io.Writer) {
 			continue
 		}
 
-		for _, statement := range program.Statements {
-			io.WriteString(out, statement.String())
+		evaluated := e.Eval(program, env)
+
+		if object.NULL_OBJ != evaluated.Type() {
+			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
 		}
 	}
@@ -0,0 +1,380 @@
+package semantic
+
+import (
+	"fmt"
+
+	"../ast"
+	"../diagnostic"
+	"../token"
+)
+
+// Analyzer : semantic pass that builds the symbol tables while checking every name against them and typing every
+// expression
+type Analyzer struct {
+	scope       *Scope
+	types       map[ast.Expression]Type
+	diagnostics diagnostic.List
+}
+
+// report :
+func (a *Analyzer) report(severity diagnostic.Severity, code diagnostic.Code, tok token.Token, format string, args ...interface{}) {
+	a.diagnostics = append(a.diagnostics, diagnostic.Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Start:    tok.Position,
+		End:      tok.End(),
+		Found:    tok,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// reportNode : diagnostic spanning the whole source of the node
+func (a *Analyzer) reportNode(severity diagnostic.Severity, code diagnostic.Code, node ast.Node, format string, args ...interface{}) {
+	a.diagnostics = append(a.diagnostics, diagnostic.Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Start:    node.Pos(),
+		End:      node.End(),
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// openScope :
+func (a *Analyzer) openScope(kind ScopeKind, name string) {
+	a.scope = InitializeScope(kind, name, a.scope)
+}
+
+// closeScope :
+func (a *Analyzer) closeScope() {
+	a.scope = a.scope.outer
+}
+
+// declare : defines the symbol in the current scope, reporting redeclarations and shadowed names
+func (a *Analyzer) declare(tok token.Token, symbol *Symbol) {
+	symbol.Position = tok.Position
+
+	if previous, ok := a.scope.LookupLocal(symbol.Name); ok {
+		a.report(diagnostic.ERROR, diagnostic.REDECLARED_IDENTIFIER, tok, "%s redeclared in this scope, previous declaration at %s", symbol.Name, previous.Position)
+
+		return
+	}
+
+	if previous, ok := a.scope.Lookup(symbol.Name); ok {
+		a.report(diagnostic.WARNING, diagnostic.SHADOWED_IDENTIFIER, tok, "%s shadows the %s declared at %s", symbol.Name, previous.Kind, previous.Position)
+	}
+
+	a.scope.Define(symbol)
+}
+
+// resolve :
+func (a *Analyzer) resolve(identifier *ast.Identifier) (*Symbol, bool) {
+	symbol, ok := a.scope.Lookup(identifier.Value)
+
+	if !ok {
+		a.report(diagnostic.ERROR, diagnostic.UNDECLARED_IDENTIFIER, identifier.Token, "undeclared identifier %s", identifier.Value)
+	}
+
+	return symbol, ok
+}
+
+// resolveAssignable : type of a name that can be assigned to or read into
+func (a *Analyzer) resolveAssignable(identifier *ast.Identifier) Type {
+	symbol, ok := a.resolve(identifier)
+
+	if !ok {
+		return INVALID_TYPE
+	}
+
+	if VARIABLE_SYMBOL != symbol.Kind && PARAMETER_SYMBOL != symbol.Kind {
+		a.report(diagnostic.ERROR, diagnostic.NOT_ASSIGNABLE, identifier.Token, "cannot assign to %s %s", symbol.Kind, symbol.Name)
+
+		return INVALID_TYPE
+	}
+
+	return TypeOfKeyword(symbol.Type)
+}
+
+// expectAssignable : reports a value that cannot be stored where the target type is expected
+func (a *Analyzer) expectAssignable(node ast.Node, target Type, value Type, context string) {
+	if !value.AssignableTo(target) {
+		a.reportNode(diagnostic.ERROR, diagnostic.MISMATCHED_TYPES, node, "cannot use %s value as %s in %s", value, target, context)
+	}
+}
+
+// expectType : reports an expression whose type is not the one required by the construct it is in
+func (a *Analyzer) expectType(node ast.Node, expected Type, got Type, context string) {
+	if INVALID_TYPE != got && expected != got {
+		a.reportNode(diagnostic.ERROR, diagnostic.MISMATCHED_TYPES, node, "%s must be %s, got %s", context, expected, got)
+	}
+}
+
+// Analyze :
+func (a *Analyzer) Analyze(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.Program:
+		a.analyzeStatements(node.Statements)
+	case *ast.ExpressionStatement:
+		t := a.expression(node.Expression)
+
+		// "show;" does nothing at all, procedures are only called with parentheses
+		if identifier, ok := node.Expression.(*ast.Identifier); ok && VOID_TYPE == t {
+			a.reportNode(diagnostic.ERROR, diagnostic.UNCALLED_PROCEDURE, identifier, "%s is not called, write %s() to call it", identifier.Value, identifier.Value)
+		}
+	case *ast.BlockStatement:
+		a.analyzeStatements(node.Statements)
+	case *ast.ProgramLiteral:
+		a.analyzeProgramLiteral(node)
+	case *ast.ProcedureLiteral:
+		a.analyzeProcedureLiteral(node)
+	case *ast.VarStatement:
+		if nil != node.Value {
+			value := a.expression(node.Value)
+			a.expectAssignable(node.Value, TypeOfKeyword(node.Type), value, "declaration of "+node.Name.Value)
+		}
+
+		a.declare(node.Name.Token, &Symbol{
+			Name: node.Name.Value,
+			Kind: VARIABLE_SYMBOL,
+			Type: node.Type,
+		})
+	case *ast.ConstStatement:
+		value := a.expression(node.Value)
+		a.expectAssignable(node.Value, TypeOfKeyword(node.Type), value, "declaration of "+node.Name.Value)
+		a.declare(node.Name.Token, &Symbol{
+			Name: node.Name.Value,
+			Kind: CONSTANT_SYMBOL,
+			Type: node.Type,
+		})
+	case *ast.AssignStatement:
+		value := a.expression(node.Value)
+		target := a.resolveAssignable(node.Name)
+		a.expectAssignable(node.Value, target, value, "assignment to "+node.Name.Value)
+	case *ast.ReadStatement:
+		for _, argument := range node.Arguments {
+			a.resolveAssignable(argument)
+		}
+	case *ast.WriteStatement:
+		// procedures and calls have no value to write
+		for _, argument := range node.Arguments {
+			if VOID_TYPE == a.expression(argument) {
+				a.reportNode(diagnostic.ERROR, diagnostic.INVALID_OPERANDS, argument, "invalid operation: %s %s", node.Token.Literal, VOID_TYPE)
+			}
+		}
+	case *ast.ConditionalExpression:
+		a.expectType(node.Condition, BOOLEAN_TYPE, a.expression(node.Condition), "if condition")
+		a.Analyze(node.Consequence)
+
+		if nil != node.Alternative {
+			a.Analyze(node.Alternative)
+		}
+	case *ast.WhileLiteral:
+		a.expectType(node.Condition, BOOLEAN_TYPE, a.expression(node.Condition), "while condition")
+		a.Analyze(node.Body)
+	case *ast.ForLiteral:
+		a.expectType(node.Variable, INTEGER_TYPE, a.resolveAssignable(node.Variable), "for loop variable "+node.Variable.Value)
+		a.expectType(node.From, INTEGER_TYPE, a.expression(node.From), "for loop initial value")
+		a.expectType(node.To, INTEGER_TYPE, a.expression(node.To), "for loop final value")
+		a.Analyze(node.Body)
+	case *ast.Identifier, *ast.IntegerLiteral, *ast.RealLiteral, *ast.StringLiteral, *ast.PrefixExpression, *ast.InfixExpression, *ast.CallExpression:
+		a.expression(node.(ast.Expression))
+	}
+}
+
+// expression : type of the expression, which is also recorded for TypeOf
+func (a *Analyzer) expression(node ast.Expression) Type {
+	t := VOID_TYPE
+
+	switch node := node.(type) {
+	case *ast.IntegerLiteral:
+		t = INTEGER_TYPE
+	case *ast.RealLiteral:
+		t = REAL_TYPE
+	case *ast.StringLiteral:
+		t = STRING_TYPE
+	case *ast.Identifier:
+		t = a.analyzeIdentifier(node)
+	case *ast.PrefixExpression:
+		t = a.analyzePrefixExpression(node)
+	case *ast.InfixExpression:
+		t = a.analyzeInfixExpression(node)
+	case *ast.CallExpression:
+		a.analyzeCallExpression(node)
+	default:
+		a.Analyze(node)
+	}
+
+	a.types[node] = t
+
+	return t
+}
+
+// analyzeIdentifier : programs and procedures have no value
+func (a *Analyzer) analyzeIdentifier(node *ast.Identifier) Type {
+	symbol, ok := a.resolve(node)
+
+	if !ok {
+		return INVALID_TYPE
+	}
+
+	if PROGRAM_SYMBOL == symbol.Kind || PROCEDURE_SYMBOL == symbol.Kind {
+		return VOID_TYPE
+	}
+
+	return TypeOfKeyword(symbol.Type)
+}
+
+// analyzePrefixExpression :
+func (a *Analyzer) analyzePrefixExpression(node *ast.PrefixExpression) Type {
+	right := a.expression(node.Right)
+
+	if INVALID_TYPE == right {
+		return INVALID_TYPE
+	}
+
+	t, ok := Unary(node.Token.Type, right)
+
+	if !ok {
+		a.reportNode(diagnostic.ERROR, diagnostic.INVALID_OPERANDS, node, "invalid operation: %s %s", node.Operator, right)
+	}
+
+	return t
+}
+
+// analyzeInfixExpression :
+func (a *Analyzer) analyzeInfixExpression(node *ast.InfixExpression) Type {
+	left := a.expression(node.Left)
+	right := a.expression(node.Right)
+
+	if INVALID_TYPE == left || INVALID_TYPE == right {
+		return INVALID_TYPE
+	}
+
+	t, ok := Binary(node.Token.Type, left, right)
+
+	if !ok {
+		a.reportNode(diagnostic.ERROR, diagnostic.INVALID_OPERANDS, node, "invalid operation: %s %s %s", left, node.Operator, right)
+	}
+
+	return t
+}
+
+// analyzeStatements :
+func (a *Analyzer) analyzeStatements(statements []ast.Statement) {
+	for _, statement := range statements {
+		a.Analyze(statement)
+	}
+}
+
+// analyzeProgramLiteral : the program gets its own scope, where its name is also declared
+func (a *Analyzer) analyzeProgramLiteral(node *ast.ProgramLiteral) {
+	a.openScope(PROGRAM_SCOPE, node.Name)
+
+	a.declare(node.Token, &Symbol{
+		Name: node.Name,
+		Kind: PROGRAM_SYMBOL,
+	})
+
+	a.analyzeStatements(node.Declarations)
+
+	for _, procedure := range node.Procedures {
+		a.analyzeProcedureLiteral(procedure)
+	}
+
+	a.Analyze(node.Body)
+	a.closeScope()
+}
+
+// analyzeProcedureLiteral : declared before its body is analyzed so it may call itself
+func (a *Analyzer) analyzeProcedureLiteral(node *ast.ProcedureLiteral) {
+	a.declare(node.Token, &Symbol{
+		Name:      node.Name,
+		Kind:      PROCEDURE_SYMBOL,
+		Procedure: node,
+	})
+
+	a.openScope(PARAMETER_SCOPE, node.Name)
+
+	for _, parameter := range node.Parameters {
+		a.declare(parameter.Token, &Symbol{
+			Name: parameter.Value,
+			Kind: PARAMETER_SYMBOL,
+			Type: parameter.Type,
+		})
+	}
+
+	a.openScope(PROCEDURE_SCOPE, node.Name)
+	a.analyzeStatements(node.Declarations)
+	a.Analyze(node.Body)
+	a.closeScope()
+	a.closeScope()
+}
+
+// analyzeCallExpression : arguments are checked against the parameters they are passed as
+func (a *Analyzer) analyzeCallExpression(node *ast.CallExpression) {
+	var procedure *ast.ProcedureLiteral
+
+	if identifier, ok := node.Procedure.(*ast.Identifier); ok {
+		symbol, ok := a.resolve(identifier)
+
+		if ok && PROCEDURE_SYMBOL != symbol.Kind {
+			a.report(diagnostic.ERROR, diagnostic.NOT_A_PROCEDURE, identifier.Token, "cannot call %s %s, it is not a procedure", symbol.Kind, symbol.Name)
+		}
+
+		if ok {
+			procedure = symbol.Procedure
+		}
+	} else {
+		a.expression(node.Procedure)
+	}
+
+	arguments := []Type{}
+
+	for _, argument := range node.Arguments {
+		arguments = append(arguments, a.expression(argument))
+	}
+
+	if nil == procedure {
+		return
+	}
+
+	if len(procedure.Parameters) != len(arguments) {
+		a.reportNode(diagnostic.ERROR, diagnostic.WRONG_ARGUMENT_COUNT, node, "wrong number of arguments for %s, want=%d, got=%d", procedure.Name, len(procedure.Parameters), len(arguments))
+
+		return
+	}
+
+	for i, parameter := range procedure.Parameters {
+		a.expectAssignable(node.Arguments[i], TypeOfKeyword(parameter.Type), arguments[i], fmt.Sprintf("argument %d of %s", i+1, procedure.Name))
+	}
+}
+
+// TypeOf : type given to the expression, INVALID_TYPE when it was not analyzed
+func (a *Analyzer) TypeOf(node ast.Expression) Type {
+	if t, ok := a.types[node]; ok {
+		return t
+	}
+
+	return INVALID_TYPE
+}
+
+// Scope : outermost scope, where the loose declarations are kept
+func (a *Analyzer) Scope() *Scope {
+	return a.scope
+}
+
+// Diagnostics : semantic diagnostics sorted by their position in the source
+func (a *Analyzer) Diagnostics() diagnostic.List {
+	diagnostics := append(diagnostic.List{}, a.diagnostics...)
+	diagnostics.Sort()
+
+	return diagnostics
+}
+
+// InitializeAnalyzer :
+func InitializeAnalyzer() *Analyzer {
+	return &Analyzer{
+		scope:       InitializeScope(PROGRAM_SCOPE, "", nil),
+		types:       make(map[ast.Expression]Type),
+		diagnostics: diagnostic.List{},
+	}
+}
@@ -0,0 +1,91 @@
+package semantic
+
+import (
+	"../ast"
+	"../token"
+)
+
+// SymbolKind :
+type SymbolKind string
+
+const (
+	PROGRAM_SYMBOL   SymbolKind = "program"
+	VARIABLE_SYMBOL  SymbolKind = "variable"
+	CONSTANT_SYMBOL  SymbolKind = "constant"
+	PARAMETER_SYMBOL SymbolKind = "parameter"
+	PROCEDURE_SYMBOL SymbolKind = "procedure"
+)
+
+// ScopeKind :
+type ScopeKind string
+
+const (
+	PROGRAM_SCOPE   ScopeKind = "program"
+	PARAMETER_SCOPE ScopeKind = "parameter"
+	PROCEDURE_SCOPE ScopeKind = "procedure"
+)
+
+// Symbol : a declared name
+type Symbol struct {
+	Name string
+	Kind SymbolKind
+	// Declared type keyword, empty for programs and procedures
+	Type      token.Token
+	Position  token.Position
+	Procedure *ast.ProcedureLiteral
+}
+
+// Scope : symbol table of a program, a procedure parameter list or a procedure body
+type Scope struct {
+	Kind    ScopeKind
+	Name    string
+	symbols map[string]*Symbol
+	outer   *Scope
+}
+
+// Outer : enclosing scope, nil for the outermost one
+func (s *Scope) Outer() *Scope {
+	return s.outer
+}
+
+// Define : adds the symbol to this scope
+func (s *Scope) Define(symbol *Symbol) {
+	s.symbols[symbol.Name] = symbol
+}
+
+// LookupLocal : looks the name up in this scope only, a procedure body also sees its parameters as local since both
+// belong to the same declaration level
+func (s *Scope) LookupLocal(name string) (*Symbol, bool) {
+	if symbol, ok := s.symbols[name]; ok {
+		return symbol, true
+	}
+
+	if PROCEDURE_SCOPE == s.Kind && nil != s.outer && PARAMETER_SCOPE == s.outer.Kind {
+		return s.outer.LookupLocal(name)
+	}
+
+	return nil, false
+}
+
+// Lookup : looks the name up in this scope and then in the enclosing ones
+func (s *Scope) Lookup(name string) (*Symbol, bool) {
+	if symbol, ok := s.symbols[name]; ok {
+		return symbol, true
+	}
+
+	if nil != s.outer {
+		return s.outer.Lookup(name)
+	}
+
+	return nil, false
+}
+
+// InitializeScope :
+func InitializeScope(kind ScopeKind, name string, outer *Scope) *Scope {
+	return &Scope{
+		Kind:    kind,
+		Name:    name,
+		symbols: make(map[string]*Symbol),
+		outer:   outer,
+	}
+}
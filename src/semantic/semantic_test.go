@@ -0,0 +1,354 @@
+package semantic
+
+import (
+	"testing"
+
+	"../ast"
+	"../diagnostic"
+	"../lexer"
+	"../parser"
+)
+
+// testAnalyze : semantic diagnostics of a program that parses cleanly
+func testAnalyze(t *testing.T, input string) diagnostic.List {
+	l := lexer.InitializeLexer(input)
+	p := parser.InitializeParser(l)
+	program := p.ParseProgram()
+
+	if 0 != len(p.Errors()) {
+		t.Fatalf("parser errors for %q: %q", input, p.Errors())
+	}
+
+	a := InitializeAnalyzer()
+	a.Analyze(program)
+
+	return a.Diagnostics()
+}
+
+func TestScopes(t *testing.T) {
+	program := InitializeScope(PROGRAM_SCOPE, "p", nil)
+	program.Define(&Symbol{Name: "a", Kind: VARIABLE_SYMBOL})
+
+	parameters := InitializeScope(PARAMETER_SCOPE, "f", program)
+	parameters.Define(&Symbol{Name: "x", Kind: PARAMETER_SYMBOL})
+
+	body := InitializeScope(PROCEDURE_SCOPE, "f", parameters)
+	body.Define(&Symbol{Name: "b", Kind: VARIABLE_SYMBOL})
+
+	tests := []struct {
+		name         string
+		local        bool
+		found        bool
+		expectedKind SymbolKind
+	}{
+		{"b", true, true, VARIABLE_SYMBOL},
+		{"x", true, true, PARAMETER_SYMBOL},
+		{"a", false, true, VARIABLE_SYMBOL},
+		{"c", false, false, ""},
+	}
+
+	for _, tt := range tests {
+		_, local := body.LookupLocal(tt.name)
+
+		if local != tt.local {
+			t.Errorf("LookupLocal(%q) wrong, expected=%t, got=%t", tt.name, tt.local, local)
+		}
+
+		symbol, found := body.Lookup(tt.name)
+
+		if found != tt.found {
+			t.Fatalf("Lookup(%q) wrong, expected=%t, got=%t", tt.name, tt.found, found)
+		}
+
+		if found && symbol.Kind != tt.expectedKind {
+			t.Errorf("symbol %q has wrong kind, expected=%q, got=%q", tt.name, tt.expectedKind, symbol.Kind)
+		}
+	}
+
+	if _, ok := parameters.LookupLocal("b"); ok {
+		t.Errorf("parameter scope should not see the procedure body declarations")
+	}
+}
+
+func TestDeclarationDiagnostics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			"var a: integer := 1; a := a + 1;",
+			[]string{},
+		},
+		{
+			"a := 1;",
+			[]string{"1:1: error[S001]: undeclared identifier a"},
+		},
+		{
+			"var a: integer := b * 2;",
+			[]string{"1:19: error[S001]: undeclared identifier b"},
+		},
+		{
+			"var a: integer;\nvar a: real;",
+			[]string{"2:5: error[S002]: a redeclared in this scope, previous declaration at 1:5"},
+		},
+		{
+			"const a: integer := 1; a := 2; read(a);",
+			[]string{
+				"1:24: error[S004]: cannot assign to constant a",
+				"1:37: error[S004]: cannot assign to constant a",
+			},
+		},
+		{
+			"var a: integer; a(1);",
+			[]string{"1:17: error[S005]: cannot call variable a, it is not a procedure"},
+		},
+		{
+			"for i := 1 to 2 do write(i);",
+			[]string{
+				"1:5: error[S001]: undeclared identifier i",
+				"1:26: error[S001]: undeclared identifier i",
+			},
+		},
+		{
+			"if x > 1 then write(y) end else writeln(z) end",
+			[]string{
+				"1:4: error[S001]: undeclared identifier x",
+				"1:21: error[S001]: undeclared identifier y",
+				"1:41: error[S001]: undeclared identifier z",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		diagnostics := testAnalyze(t, tt.input)
+
+		if len(diagnostics) != len(tt.expected) {
+			t.Fatalf("wrong number of diagnostics for %q, expected=%d, got=%q", tt.input, len(tt.expected), diagnostics.Strings())
+		}
+
+		for i, d := range diagnostics {
+			if d.Format() != tt.expected[i] {
+				t.Errorf("wrong diagnostic for %q, expected=%q, got=%q", tt.input, tt.expected[i], d.Format())
+			}
+		}
+	}
+}
+
+func TestProgramScopes(t *testing.T) {
+	input := `program scopes;
+var a: integer;
+var b: integer;
+procedure f(a: integer, c: real);
+var c: integer;
+var d: integer;
+begin
+	d := a + b;
+	f(d, e)
+end;
+procedure g;
+begin
+	f(a, b);
+	d := 1
+end;
+begin
+	g();
+	a := 1
+end.`
+
+	expected := []string{
+		"4:13: warning[S003]: a shadows the variable declared at 2:5",
+		"5:5: error[S002]: c redeclared in this scope, previous declaration at 4:25",
+		"9:7: error[S001]: undeclared identifier e",
+		"14:2: error[S001]: undeclared identifier d",
+	}
+
+	diagnostics := testAnalyze(t, input)
+
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("wrong number of diagnostics, expected=%d, got=%q", len(expected), diagnostics.Strings())
+	}
+
+	for i, d := range diagnostics {
+		if d.Format() != expected[i] {
+			t.Errorf("diagnostics[%d] wrong, expected=%q, got=%q", i, expected[i], d.Format())
+		}
+	}
+
+	if !diagnostics.HasErrors() {
+		t.Errorf("diagnostics should have errors")
+	}
+}
+
+func TestTypeChecking(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			"var x: real := 2; var y: integer := 3 div 2 + 4 mod 3; x := y * 1.5;",
+			[]string{},
+		},
+		{
+			"var x: integer := 2.5;",
+			[]string{"1:19: error[S006]: cannot use real value as integer in declaration of x"},
+		},
+		{
+			"const x: integer := 1 / 1;",
+			[]string{"1:21: error[S006]: cannot use real value as integer in declaration of x"},
+		},
+		{
+			"var x: integer; var y: real; x := y;",
+			[]string{"1:35: error[S006]: cannot use real value as integer in assignment to x"},
+		},
+		{
+			"var x: integer; x := 1 < 2;",
+			[]string{"1:22: error[S006]: cannot use boolean value as integer in assignment to x"},
+		},
+		{
+			"var x: real := 1.5 div 2;",
+			[]string{"1:16: error[S007]: invalid operation: real div integer"},
+		},
+		{
+			"var x: integer := 3 mod 2.0;",
+			[]string{"1:19: error[S007]: invalid operation: integer mod real"},
+		},
+		{
+			"var x: integer := -(1 > 2) + 1;",
+			[]string{"1:19: error[S007]: invalid operation: - boolean"},
+		},
+		{
+			"var x: integer := 1; write(not x, x and x, (x < 2) < (x > 1));",
+			[]string{
+				"1:28: error[S007]: invalid operation: not integer",
+				"1:35: error[S007]: invalid operation: integer and integer",
+				"1:44: error[S007]: invalid operation: boolean < boolean",
+			},
+		},
+		{
+			"var x: integer := 1; if x then write(x) end; while x + 1 do x := 0",
+			[]string{
+				"1:25: error[S006]: if condition must be boolean, got integer",
+				"1:52: error[S006]: while condition must be boolean, got integer",
+			},
+		},
+		{
+			"var x: integer; var y: real; for y := 1 to 2.5 do x := 1",
+			[]string{
+				"1:34: error[S006]: for loop variable y must be integer, got real",
+				"1:44: error[S006]: for loop final value must be integer, got real",
+			},
+		},
+		{
+			"var x: integer; write(x + z, (z > 1.5) and (x = 1));",
+			[]string{
+				"1:27: error[S001]: undeclared identifier z",
+				"1:31: error[S001]: undeclared identifier z",
+			},
+		},
+		{
+			"var x: integer := 'a'; writeln('x = ', x); x := x + 'b';",
+			[]string{
+				"1:19: error[S006]: cannot use string value as integer in declaration of x",
+				"1:49: error[S007]: invalid operation: integer + string",
+			},
+		},
+		{
+			"procedure p(a: integer); begin end; writeln(p(1)); write('p = ', p);",
+			[]string{
+				"1:45: error[S007]: invalid operation: writeln void",
+				"1:66: error[S007]: invalid operation: write void",
+			},
+		},
+		{
+			"procedure show; begin writeln end; show; show()",
+			[]string{"1:36: error[S009]: show is not called, write show() to call it"},
+		},
+	}
+
+	for _, tt := range tests {
+		diagnostics := testAnalyze(t, tt.input)
+
+		if len(diagnostics) != len(tt.expected) {
+			t.Fatalf("wrong number of diagnostics for %q, expected=%d, got=%q", tt.input, len(tt.expected), diagnostics.Strings())
+		}
+
+		for i, d := range diagnostics {
+			if d.Format() != tt.expected[i] {
+				t.Errorf("wrong diagnostic for %q, expected=%q, got=%q", tt.input, tt.expected[i], d.Format())
+			}
+		}
+	}
+}
+
+func TestProcedureArguments(t *testing.T) {
+	input := `program calls;
+var a: integer;
+var b: real;
+procedure f(x: integer, y: real);
+begin
+	write(x, y)
+end;
+begin
+	f(a, a);
+	f(b, b);
+	f(a);
+	a := f(a, b)
+end.`
+
+	expected := []string{
+		"10:4: error[S006]: cannot use real value as integer in argument 1 of f",
+		"11:2: error[S008]: wrong number of arguments for f, want=2, got=1",
+		"12:7: error[S006]: cannot use void value as integer in assignment to a",
+	}
+
+	diagnostics := testAnalyze(t, input)
+
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("wrong number of diagnostics, expected=%d, got=%q", len(expected), diagnostics.Strings())
+	}
+
+	for i, d := range diagnostics {
+		if d.Format() != expected[i] {
+			t.Errorf("diagnostics[%d] wrong, expected=%q, got=%q", i, expected[i], d.Format())
+		}
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Type
+	}{
+		{"1", INTEGER_TYPE},
+		{"1.5", REAL_TYPE},
+		{"1 + 2.5", REAL_TYPE},
+		{"4 / 2", REAL_TYPE},
+		{"4 div 2", INTEGER_TYPE},
+		{"-3 mod 2", INTEGER_TYPE},
+		{"1 = 1.0", BOOLEAN_TYPE},
+		{"not (1 < 2) or (2 >= 1)", BOOLEAN_TYPE},
+	}
+
+	for _, tt := range tests {
+		l := lexer.InitializeLexer(tt.input)
+		p := parser.InitializeParser(l)
+		program := p.ParseProgram()
+
+		if 0 != len(p.Errors()) {
+			t.Fatalf("parser errors for %q: %q", tt.input, p.Errors())
+		}
+
+		a := InitializeAnalyzer()
+		a.Analyze(program)
+
+		statement, ok := program.Statements[0].(*ast.ExpressionStatement)
+
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement, got=%T", program.Statements[0])
+		}
+
+		if got := a.TypeOf(statement.Expression); got != tt.expected {
+			t.Errorf("wrong type for %q, expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package semantic
+
+import (
+	"../token"
+)
+
+// Type : static type of an expression
+type Type string
+
+const (
+	INTEGER_TYPE Type = "integer"
+	REAL_TYPE    Type = "real"
+	BOOLEAN_TYPE Type = "boolean"
+	// String literals, which can only be written
+	STRING_TYPE Type = "string"
+	// Procedure calls, programs and procedures used as values
+	VOID_TYPE Type = "void"
+	// Expressions whose type could not be decided, an error was already reported for them
+	INVALID_TYPE Type = "invalid"
+)
+
+// TypeOfKeyword : type named by the keyword of a declaration
+func TypeOfKeyword(keyword token.Token) Type {
+	switch keyword.Type {
+	case token.INTEGER_KEYWORD:
+		return INTEGER_TYPE
+	case token.REAL_KEYWORD:
+		return REAL_TYPE
+	}
+
+	return INVALID_TYPE
+}
+
+// IsNumeric :
+func (t Type) IsNumeric() bool {
+	return INTEGER_TYPE == t || REAL_TYPE == t
+}
+
+// AssignableTo : integers widen to reals, reals never narrow to integers
+func (t Type) AssignableTo(target Type) bool {
+	if INVALID_TYPE == t || INVALID_TYPE == target || t == target {
+		return true
+	}
+
+	return INTEGER_TYPE == t && REAL_TYPE == target
+}
+
+// Unary : type of a prefix operation and whether the operand is accepted
+func Unary(operator token.TokenType, right Type) (Type, bool) {
+	switch operator {
+	case token.MINUS:
+		if right.IsNumeric() {
+			return right, true
+		}
+	case token.NOT:
+		if BOOLEAN_TYPE == right {
+			return BOOLEAN_TYPE, true
+		}
+	}
+
+	return INVALID_TYPE, false
+}
+
+// Binary : type of an infix operation and whether the operands are accepted, following the same rules the runtime
+// applies in the object package
+func Binary(operator token.TokenType, left Type, right Type) (Type, bool) {
+	numeric := left.IsNumeric() && right.IsNumeric()
+
+	switch operator {
+	case token.PLUS, token.MINUS, token.ASTERISK:
+		if INTEGER_TYPE == left && INTEGER_TYPE == right {
+			return INTEGER_TYPE, true
+		}
+
+		if numeric {
+			return REAL_TYPE, true
+		}
+	case token.SLASH:
+		if numeric {
+			return REAL_TYPE, true
+		}
+	case token.DIV, token.MOD:
+		if INTEGER_TYPE == left && INTEGER_TYPE == right {
+			return INTEGER_TYPE, true
+		}
+	case token.AND, token.OR:
+		if BOOLEAN_TYPE == left && BOOLEAN_TYPE == right {
+			return BOOLEAN_TYPE, true
+		}
+	case token.EQUAL, token.DIFFERENT:
+		if numeric || (BOOLEAN_TYPE == left && BOOLEAN_TYPE == right) {
+			return BOOLEAN_TYPE, true
+		}
+	case token.LESS_THAN, token.LESS_THAN_EQUAL, token.GREATER_THAN, token.GREATER_THAN_EQUAL:
+		if numeric {
+			return BOOLEAN_TYPE, true
+		}
+	}
+
+	return INVALID_TYPE, false
+}
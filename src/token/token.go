@@ -1,97 +1,251 @@
 package token
 
-// TokenType : this will work as a PoC only, needs to change it to an int or a byte later on
-type TokenType string
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// TokenType : kind of a token, its String being the name used in messages
+type TokenType int
+
+// Position : where a token starts in the source; lines and columns are counted from one, columns in runes, the offset is in bytes from zero
+type Position struct {
+	Line   int
+	Column int
+	Offset int
+}
+
+// String :
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
 
-// Token : stores the information token related; later on add a line and column to it to make an easier debug later on
+// Token : stores the information token related
 type Token struct {
-	Type    TokenType
-	Literal string
+	Type     TokenType
+	Literal  string
+	Position Position
+}
+
+// End : position right after the last char of the token
+func (t Token) End() Position {
+	return Position{
+		Line:   t.Position.Line,
+		Column: t.Position.Column + utf8.RuneCountInString(t.Literal),
+		Offset: t.Position.Offset + len(t.Literal),
+	}
 }
 
 const (
-	EOF     = "EOF"
-	ILLEGAL = "ILLEGAL"
-
-	VAR   = "VAR"
-	CONST = "CONST"
-
-	REAL_KEYWORD    = "REAL_KEYWORD"
-	INTEGER_KEYWORD = "INTEGER_KEYWORD"
-
-	IDENTIFIER = "IDENTIFIER"
-	INTEGER    = "INTEGER"
-	REAL       = "REAL"
-
-	PROGRAM   = "PROGRAM"
-	PROCEDURE = "PROCEDURE"
-	BEGIN     = "BEGIN"
-	DO        = "DO"
-	END       = "END"
-
-	FOR   = "FOR"
-	TO    = "TO"
-	WHILE = "WHILE"
-
-	IF   = "IF"
-	THEN = "THEN"
-	ELSE = "ELSE"
-
-	ASSIGN   = ":="
-	PLUS     = "+"
-	MINUS    = "-"
-	SLASH    = "/"
-	ASTERISK = "*"
-
-	LESS_THAN          = "<"
-	GREATER_THAN       = ">"
-	LESS_THAN_EQUAL    = "<="
-	GREATER_THAN_EQUAl = ">="
-	EQUAL              = "=="
-	DIFFERENT          = "<>"
-
-	COMMA             = ","
-	COLON             = ":"
-	SEMICOLON         = ";"
-	LEFT_PARENTHESIS  = "("
-	RIGHT_PARENTHESIS = ")"
-	RIGHT_BRACES      = "{"
-	LEFT_BRACES       = "}"
+	EOF TokenType = iota
+	ILLEGAL
+	// Never returned by the lexer, comments are kept apart as trivia
+	COMMENT
+
+	VAR
+	CONST
+
+	REAL_KEYWORD
+	INTEGER_KEYWORD
+
+	IDENTIFIER
+	INTEGER
+	REAL
+	STRING
+
+	PROGRAM
+	PROCEDURE
+	BEGIN
+	DO
+	END
+
+	FOR
+	TO
+	WHILE
+
+	IF
+	THEN
+	ELSE
+
+	READ
+	READLN
+	WRITE
+	WRITELN
+
+	AND
+	OR
+	NOT
+	DIV
+	MOD
+
+	ASSIGN
+	PLUS
+	MINUS
+	SLASH
+	ASTERISK
+
+	LESS_THAN
+	GREATER_THAN
+	LESS_THAN_EQUAL
+	GREATER_THAN_EQUAL
+	EQUAL
+	DIFFERENT
+
+	COMMA
+	COLON
+	SEMICOLON
+	DOT
+	LEFT_PARENTHESIS
+	RIGHT_PARENTHESIS
 )
 
-var keywords = map[string]TokenType{
-	"if":        IF,
-	"do":        DO,
-	"to":        TO,
-	"var":       VAR,
-	"for":       FOR,
-	"end":       END,
-	"else":      ELSE,
-	"then":      THEN,
-	"+":         PLUS,
-	"const":     CONST,
-	"while":     WHILE,
-	"begin":     BEGIN,
-	"==":        EQUAL,
-	":":         COLON,
-	"-":         MINUS,
-	"/":         SLASH,
-	":=":        ASSIGN,
-	"program":   PROGRAM,
-	"*":         ASTERISK,
-	"procedure": PROCEDURE,
-	"<":         LESS_THAN,
-	">":         GREATER_THAN,
-	"real":      REAL_KEYWORD,
-	"integer":   INTEGER_KEYWORD,
-	"<=":        LESS_THAN_EQUAL,
-	">=":        GREATER_THAN_EQUAl,
+// names : operators and punctuation are named after their symbol
+var names = [...]string{
+	EOF:     "EOF",
+	ILLEGAL: "ILLEGAL",
+	COMMENT: "COMMENT",
+
+	VAR:   "VAR",
+	CONST: "CONST",
+
+	REAL_KEYWORD:    "REAL_KEYWORD",
+	INTEGER_KEYWORD: "INTEGER_KEYWORD",
+
+	IDENTIFIER: "IDENTIFIER",
+	INTEGER:    "INTEGER",
+	REAL:       "REAL",
+	STRING:     "STRING",
+
+	PROGRAM:   "PROGRAM",
+	PROCEDURE: "PROCEDURE",
+	BEGIN:     "BEGIN",
+	DO:        "DO",
+	END:       "END",
+
+	FOR:   "FOR",
+	TO:    "TO",
+	WHILE: "WHILE",
+
+	IF:   "IF",
+	THEN: "THEN",
+	ELSE: "ELSE",
+
+	READ:    "READ",
+	READLN:  "READLN",
+	WRITE:   "WRITE",
+	WRITELN: "WRITELN",
+
+	AND: "AND",
+	OR:  "OR",
+	NOT: "NOT",
+	DIV: "DIV",
+	MOD: "MOD",
+
+	ASSIGN:   ":=",
+	PLUS:     "+",
+	MINUS:    "-",
+	SLASH:    "/",
+	ASTERISK: "*",
+
+	LESS_THAN:          "<",
+	GREATER_THAN:       ">",
+	LESS_THAN_EQUAL:    "<=",
+	GREATER_THAN_EQUAL: ">=",
+	EQUAL:              "=",
+	DIFFERENT:          "<>",
+
+	COMMA:             ",",
+	COLON:             ":",
+	SEMICOLON:         ";",
+	DOT:               ".",
+	LEFT_PARENTHESIS:  "(",
+	RIGHT_PARENTHESIS: ")",
+}
+
+// String :
+func (t TokenType) String() string {
+	if 0 <= t && int(t) < len(names) {
+		return names[t]
+	}
+
+	return fmt.Sprintf("TokenType(%d)", int(t))
+}
+
+// MarshalText : token types are written by name, so JSON output does not depend on their order
+func (t TokenType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
 }
 
-// LookupIdentifier :
-func LookupIdentifier(identification string) TokenType {
-	if tok, ok := keywords[identification]; ok {
-		return tok
+// LookupIdentifier : reserved words are told apart by their length first, so most identifiers are compared against a handful of words at most
+func LookupIdentifier(identifier string) TokenType {
+	switch len(identifier) {
+	case 2:
+		switch identifier {
+		case "if":
+			return IF
+		case "do":
+			return DO
+		case "or":
+			return OR
+		case "to":
+			return TO
+		}
+	case 3:
+		switch identifier {
+		case "var":
+			return VAR
+		case "and":
+			return AND
+		case "not":
+			return NOT
+		case "div":
+			return DIV
+		case "mod":
+			return MOD
+		case "for":
+			return FOR
+		case "end":
+			return END
+		}
+	case 4:
+		switch identifier {
+		case "else":
+			return ELSE
+		case "then":
+			return THEN
+		case "read":
+			return READ
+		case "real":
+			return REAL_KEYWORD
+		}
+	case 5:
+		switch identifier {
+		case "const":
+			return CONST
+		case "while":
+			return WHILE
+		case "write":
+			return WRITE
+		case "begin":
+			return BEGIN
+		}
+	case 6:
+		if "readln" == identifier {
+			return READLN
+		}
+	case 7:
+		switch identifier {
+		case "program":
+			return PROGRAM
+		case "writeln":
+			return WRITELN
+		case "integer":
+			return INTEGER_KEYWORD
+		}
+	case 9:
+		if "procedure" == identifier {
+			return PROCEDURE
+		}
 	}
 
 	return IDENTIFIER
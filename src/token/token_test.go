@@ -0,0 +1,89 @@
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// TestLookupIdentifier :
+func TestLookupIdentifier(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected TokenType
+	}{
+		{"if", IF},
+		{"do", DO},
+		{"or", OR},
+		{"to", TO},
+		{"var", VAR},
+		{"and", AND},
+		{"not", NOT},
+		{"div", DIV},
+		{"mod", MOD},
+		{"for", FOR},
+		{"end", END},
+		{"else", ELSE},
+		{"then", THEN},
+		{"read", READ},
+		{"real", REAL_KEYWORD},
+		{"const", CONST},
+		{"while", WHILE},
+		{"write", WRITE},
+		{"begin", BEGIN},
+		{"readln", READLN},
+		{"program", PROGRAM},
+		{"writeln", WRITELN},
+		{"integer", INTEGER_KEYWORD},
+		{"procedure", PROCEDURE},
+		{"x", IDENTIFIER},
+		{"ifs", IDENTIFIER},
+		{"reals", IDENTIFIER},
+		{"procedures", IDENTIFIER},
+		{"Begin", IDENTIFIER},
+		{":=", IDENTIFIER},
+		{"+", IDENTIFIER},
+		{"", IDENTIFIER},
+	}
+
+	for _, tt := range tests {
+		if got := LookupIdentifier(tt.input); got != tt.expected {
+			t.Errorf("wrong type for %q, expected=%s, got=%s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+// TestTokenTypeString :
+func TestTokenTypeString(t *testing.T) {
+	tests := []struct {
+		input    TokenType
+		expected string
+	}{
+		{EOF, "EOF"},
+		{IDENTIFIER, "IDENTIFIER"},
+		{INTEGER_KEYWORD, "INTEGER_KEYWORD"},
+		{ASSIGN, ":="},
+		{DIFFERENT, "<>"},
+		{RIGHT_PARENTHESIS, ")"},
+		{TokenType(-1), "TokenType(-1)"},
+		{RIGHT_PARENTHESIS + 1, fmt.Sprintf("TokenType(%d)", int(RIGHT_PARENTHESIS)+1)},
+	}
+
+	for _, tt := range tests {
+		if tt.input.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, tt.input.String())
+		}
+	}
+
+	for i, name := range names {
+		if "" == name {
+			t.Errorf("token type %d has no name", i)
+		}
+	}
+
+	encoded, err := json.Marshal(map[string]TokenType{"type": SEMICOLON})
+
+	if nil != err || `{"type":";"}` != string(encoded) {
+		t.Errorf("wrong JSON, expected=%q, got=%q (%v)", `{"type":";"}`, encoded, err)
+	}
+}
@@ -0,0 +1,27 @@
+package vm
+
+import (
+	"../code"
+	"../object"
+)
+
+// Frame : running procedure, its locals start at the base pointer of the stack
+type Frame struct {
+	procedure   *object.CompiledProcedure
+	ip          int
+	basePointer int
+}
+
+// Instructions :
+func (f *Frame) Instructions() code.Instructions {
+	return f.procedure.Instructions
+}
+
+// InitializeFrame :
+func InitializeFrame(procedure *object.CompiledProcedure, basePointer int) *Frame {
+	return &Frame{
+		procedure:   procedure,
+		ip:          -1,
+		basePointer: basePointer,
+	}
+}
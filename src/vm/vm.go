@@ -0,0 +1,324 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+
+	"../code"
+	"../compiler"
+	"../object"
+	"../token"
+)
+
+const (
+	STACK_SIZE   = 2048
+	GLOBALS_SIZE = 65536
+	MAX_FRAMES   = 1024
+)
+
+// operators : LALG operator applied by each operator opcode, so object.Arithmetic does the actual work
+var operators = map[code.Opcode]token.TokenType{
+	code.OpAdd:              token.PLUS,
+	code.OpSub:              token.MINUS,
+	code.OpMul:              token.ASTERISK,
+	code.OpDivide:           token.SLASH,
+	code.OpDiv:              token.DIV,
+	code.OpMod:              token.MOD,
+	code.OpAnd:              token.AND,
+	code.OpOr:               token.OR,
+	code.OpEqual:            token.EQUAL,
+	code.OpNotEqual:         token.DIFFERENT,
+	code.OpLessThan:         token.LESS_THAN,
+	code.OpLessThanEqual:    token.LESS_THAN_EQUAL,
+	code.OpGreaterThan:      token.GREATER_THAN,
+	code.OpGreaterThanEqual: token.GREATER_THAN_EQUAL,
+	code.OpMinus:            token.MINUS,
+	code.OpNot:              token.NOT,
+}
+
+// VM : stack based virtual machine running the bytecode produced by the compiler
+type VM struct {
+	constants []object.Object
+	globals   []object.Object
+
+	stack []object.Object
+	// Always points to the next free slot, the top of the stack is stack[sp-1]
+	sp int
+
+	frames      []*Frame
+	framesIndex int
+
+	in         *object.Input
+	out        io.Writer
+	arithmetic object.Arithmetic
+}
+
+// currentFrame :
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[vm.framesIndex-1]
+}
+
+// pushFrame :
+func (vm *VM) pushFrame(f *Frame) {
+	vm.frames[vm.framesIndex] = f
+	vm.framesIndex++
+}
+
+// popFrame :
+func (vm *VM) popFrame() *Frame {
+	vm.framesIndex--
+
+	return vm.frames[vm.framesIndex]
+}
+
+// newError : runtime error at the source position of the instruction being executed
+func (vm *VM) newError(ip int, format string, a ...interface{}) *object.Error {
+	return &object.Error{
+		Message:  fmt.Sprintf(format, a...),
+		Position: vm.currentFrame().procedure.Positions[ip],
+	}
+}
+
+// push :
+func (vm *VM) push(obj object.Object) error {
+	if STACK_SIZE <= vm.sp {
+		return fmt.Errorf("stack overflow")
+	}
+
+	vm.stack[vm.sp] = obj
+	vm.sp++
+
+	return nil
+}
+
+// pop :
+func (vm *VM) pop() object.Object {
+	obj := vm.stack[vm.sp-1]
+	vm.sp--
+
+	return obj
+}
+
+// LastPoppedStackElement : value of the last expression statement, handy for testing
+func (vm *VM) LastPoppedStackElement() object.Object {
+	return vm.stack[vm.sp]
+}
+
+// store : converts the value to the type of the one already in the slot, a fresh slot takes it as it is
+func store(slot *object.Object, value object.Object) error {
+	if nil == *slot {
+		*slot = value
+
+		return nil
+	}
+
+	converted, err := object.Convert(*slot, value)
+
+	if nil != err {
+		return err
+	}
+
+	*slot = converted
+
+	return nil
+}
+
+// Run : executes until the main program ends, runtime errors are *object.Error values
+func (vm *VM) Run() error {
+	for vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+		vm.currentFrame().ip++
+
+		ip := vm.currentFrame().ip
+		ins := vm.currentFrame().Instructions()
+		op := code.Opcode(ins[ip])
+
+		var err error
+
+		switch op {
+		case code.OpConstant:
+			index := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			err = vm.push(vm.constants[index])
+		case code.OpPop:
+			vm.pop()
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDivide, code.OpDiv, code.OpMod, code.OpAnd, code.OpOr,
+			code.OpEqual, code.OpNotEqual, code.OpLessThan, code.OpLessThanEqual, code.OpGreaterThan, code.OpGreaterThanEqual:
+			right := vm.pop()
+			left := vm.pop()
+
+			result, failure := vm.arithmetic.Binary(operators[op], left, right)
+
+			if nil != failure {
+				return vm.newError(ip, "%s", failure)
+			}
+
+			err = vm.push(result)
+		case code.OpMinus, code.OpNot:
+			result, failure := vm.arithmetic.Unary(operators[op], vm.pop())
+
+			if nil != failure {
+				return vm.newError(ip, "%s", failure)
+			}
+
+			err = vm.push(result)
+		case code.OpJump:
+			position := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip = position - 1
+		case code.OpJumpNotTrue:
+			position := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			condition, failure := object.Condition(vm.pop())
+
+			if nil != failure {
+				return vm.newError(ip, "%s", failure)
+			}
+
+			if !condition {
+				vm.currentFrame().ip = position - 1
+			}
+		case code.OpBound:
+			if bound := vm.stack[vm.sp-1]; object.INTEGER_OBJ != bound.Type() {
+				return vm.newError(ip, "for bound is not an integer, got %s", bound.Type())
+			}
+		case code.OpGetGlobal:
+			index := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			err = vm.load(ip, vm.globals[index])
+		case code.OpSetGlobal:
+			index := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			if failure := store(&vm.globals[index], vm.pop()); nil != failure {
+				return vm.newError(ip, "%s", failure)
+			}
+		case code.OpGetLocal:
+			index := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip++
+
+			err = vm.load(ip, vm.stack[vm.currentFrame().basePointer+int(index)])
+		case code.OpSetLocal:
+			index := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip++
+
+			if failure := store(&vm.stack[vm.currentFrame().basePointer+int(index)], vm.pop()); nil != failure {
+				return vm.newError(ip, "%s", failure)
+			}
+		case code.OpCall:
+			numArgs := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip++
+
+			err = vm.callProcedure(ip, int(numArgs))
+		case code.OpReturn:
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer - 1
+
+			err = vm.push(object.NULL)
+		case code.OpRead:
+			value, failure := vm.in.Read(vm.pop())
+
+			if nil != failure {
+				return vm.newError(ip, "could not read: %s", failure)
+			}
+
+			err = vm.push(value)
+		case code.OpReadln:
+			vm.in.SkipLine()
+		case code.OpWrite:
+			io.WriteString(vm.out, vm.pop().Inspect())
+		case code.OpWriteln:
+			io.WriteString(vm.out, "\n")
+		default:
+			return vm.newError(ip, "unknown opcode %d", op)
+		}
+
+		if failure, ok := err.(*object.Error); ok {
+			return failure
+		}
+
+		if nil != err {
+			return vm.newError(ip, "%s", err)
+		}
+	}
+
+	return nil
+}
+
+// load : pushes the value of a variable, which must have been declared already
+func (vm *VM) load(ip int, value object.Object) error {
+	if nil == value {
+		return vm.newError(ip, "variable used before its declaration")
+	}
+
+	return vm.push(value)
+}
+
+// callProcedure : arguments are converted to their parameter types and become the first locals of the new frame
+func (vm *VM) callProcedure(ip int, numArgs int) error {
+	callee := vm.stack[vm.sp-1-numArgs]
+	procedure, ok := callee.(*object.CompiledProcedure)
+
+	if !ok {
+		return vm.newError(ip, "not a procedure: %s", callee.Type())
+	}
+
+	if len(procedure.Parameters) != numArgs {
+		return vm.newError(ip, "wrong number of arguments for %s: want=%d, got=%d", procedure.Name, len(procedure.Parameters), numArgs)
+	}
+
+	basePointer := vm.sp - numArgs
+
+	for i, parameter := range procedure.Parameters {
+		value, err := object.Convert(parameter, vm.stack[basePointer+i])
+
+		if nil != err {
+			return vm.newError(ip, "cannot pass argument %d of %s: %s", i+1, procedure.Name, err)
+		}
+
+		vm.stack[basePointer+i] = value
+	}
+
+	if MAX_FRAMES <= vm.framesIndex || STACK_SIZE < basePointer+procedure.NumLocals {
+		return vm.newError(ip, "stack overflow")
+	}
+
+	for i := basePointer + numArgs; i < basePointer+procedure.NumLocals; i++ {
+		vm.stack[i] = nil
+	}
+
+	vm.pushFrame(InitializeFrame(procedure, basePointer))
+	vm.sp = basePointer + procedure.NumLocals
+
+	return nil
+}
+
+// InitializeVM : read statements take their input from in and write statements print to out
+func InitializeVM(bytecode *compiler.Bytecode, in io.Reader, out io.Writer) *VM {
+	return InitializeVMWithIntegerWidth(bytecode, in, out, object.INTEGER_WIDTH)
+}
+
+// InitializeVMWithIntegerWidth : integer results that do not fit in width bits are runtime errors
+func InitializeVMWithIntegerWidth(bytecode *compiler.Bytecode, in io.Reader, out io.Writer, width int) *VM {
+	main := &object.CompiledProcedure{
+		Name:         "main",
+		Instructions: bytecode.Instructions,
+		Positions:    bytecode.Positions,
+	}
+
+	frames := make([]*Frame, MAX_FRAMES)
+	frames[0] = InitializeFrame(main, 0)
+
+	return &VM{
+		constants:   bytecode.Constants,
+		globals:     make([]object.Object, GLOBALS_SIZE),
+		stack:       make([]object.Object, STACK_SIZE),
+		sp:          0,
+		frames:      frames,
+		framesIndex: 1,
+		in:          object.InitializeInput(in, width),
+		out:         out,
+		arithmetic:  object.Arithmetic{Width: width},
+	}
+}
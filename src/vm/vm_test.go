@@ -0,0 +1,268 @@
+package vm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"../compiler"
+	"../evaluator"
+	"../lexer"
+	"../object"
+	"../parser"
+)
+
+// testRun : runs the input on the virtual machine, returning the machine, everything it wrote and its runtime error
+func testRun(t *testing.T, input string, stdin string) (*VM, string, error) {
+	l := lexer.InitializeLexer(input)
+	p := parser.InitializeParser(l)
+	program := p.ParseProgram()
+
+	if 0 != len(p.Errors()) {
+		t.Fatalf("parser errors for %q: %q", input, p.Errors())
+	}
+
+	c := compiler.InitializeCompiler()
+
+	if err := c.Compile(program); nil != err {
+		t.Fatalf("compiler error for %q: %s", input, err)
+	}
+
+	var out bytes.Buffer
+
+	vm := InitializeVM(c.Bytecode(), strings.NewReader(stdin), &out)
+	err := vm.Run()
+
+	return vm, out.String(), err
+}
+
+// testEval : output of the tree-walking evaluator, the reference the virtual machine must match
+func testEval(t *testing.T, input string, stdin string) string {
+	l := lexer.InitializeLexer(input)
+	p := parser.InitializeParser(l)
+	program := p.ParseProgram()
+
+	var out bytes.Buffer
+
+	e := evaluator.InitializeEvaluator(strings.NewReader(stdin), &out)
+	evaluated := e.Eval(program, object.InitializeEnvironment())
+
+	if failure, ok := evaluated.(*object.Error); ok {
+		t.Fatalf("evaluator error for %q: %s", input, failure.Inspect())
+	}
+
+	return out.String()
+}
+
+func TestExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5", "5"},
+		{"-5 + 10 * 2", "15"},
+		{"7 / 2", "3.5"},
+		{"7 div 2", "3"},
+		{"-7 mod 3", "-1"},
+		{"1 + 2.5", "3.5"},
+		{"2 * (1.5 - 1)", "1.0"},
+		{"1 = 1.0", "true"},
+		{"not (1 < 2)", "false"},
+		{"(1 <= 1) and (2 >= 3)", "false"},
+		{"(1 > 2) or (2 <> 3)", "true"},
+	}
+
+	for _, tt := range tests {
+		vm, _, err := testRun(t, tt.input, "")
+
+		if nil != err {
+			t.Fatalf("vm error for %q: %s", tt.input, err)
+		}
+
+		if result := vm.LastPoppedStackElement().Inspect(); result != tt.expected {
+			t.Errorf("wrong result for %q, expected=%q, got=%q", tt.input, tt.expected, result)
+		}
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 div 0", "1:3: runtime error: division by zero"},
+		{"1.5 div 2", "1:5: runtime error: unknown operator: REAL DIV REAL"},
+		{"var a: integer := 2.5;", "1:1: runtime error: type mismatch: REAL to INTEGER"},
+		{"if 1 then write(1) end", "1:1: runtime error: condition is not a boolean, got INTEGER"},
+		{"var i: integer; for i := 1 to 2.5 do write(i)", "1:17: runtime error: for bound is not an integer, got REAL"},
+		{"procedure p(a: integer); begin end; p(1, 2)", "1:38: runtime error: wrong number of arguments for p: want=1, got=2"},
+		{"procedure p(a: integer); begin end; p(1.5)", "1:38: runtime error: cannot pass argument 1 of p: type mismatch: REAL to INTEGER"},
+		{"var x: integer; x(1)", "1:18: runtime error: not a procedure: INTEGER"},
+		{"var x: integer; read(x)", "1:17: runtime error: could not read: EOF"},
+		{"procedure p; begin p() end; p()", "1:21: runtime error: stack overflow"},
+		{"procedure f(a: integer, b: integer); var c: integer; begin f(a + 1, b) end; f(1, 2)", "1:38: runtime error: stack overflow"},
+		{"9223372036854775807 + 1", "1:21: runtime error: integer overflow, 9223372036854775807 + 1 does not fit in 64 bits"},
+		{"-(-9223372036854775808)", "1:1: runtime error: integer overflow, -(-9223372036854775808) does not fit in 64 bits"},
+	}
+
+	for _, tt := range tests {
+		_, _, err := testRun(t, tt.input, "")
+
+		if nil == err {
+			t.Fatalf("expected a runtime error for %q", tt.input)
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error for %q, expected=%q, got=%q", tt.input, tt.expected, err.Error())
+		}
+	}
+}
+
+// TestMatchesEvaluator : programs must write exactly what the tree-walking evaluator writes
+func TestMatchesEvaluator(t *testing.T) {
+	tests := []struct {
+		input string
+		stdin string
+	}{
+		{
+			`program loops;
+var i: integer;
+var total: integer := 0;
+begin
+	for i := 1 to 10 do
+		total := total + i;
+	writeln(total);
+	writeln(i);
+	for i := 5 to 1 do
+		total := 0;
+	writeln(i);
+	while total > 40 do
+		begin
+			total := total - 7;
+			write(total);
+		end;
+	writeln
+end.`,
+			"",
+		},
+		{
+			`program blocks;
+var t: integer := 0;
+procedure f(k: integer);
+	begin
+		if k > 2 then writeln(k) end else begin t := k + 1; f(t) end end
+	end;
+begin
+	f(0);
+	begin
+		t := t * 2;
+		writeln(t)
+	end
+end.`,
+			"",
+		},
+		{
+			`program largest;
+var i: integer;
+begin
+	for i := 9223372036854775806 to 9223372036854775807 do write(i, ' ');
+	writeln(i)
+end.`,
+			"",
+		},
+		{
+			`program conditionals;
+var a: integer;
+var b: real;
+begin
+	readln(a);
+	read(b);
+	if a > b then writeln(a) end else writeln(b) end;
+	if a = 3 then writeln(a * b) end
+end.`,
+			"3 ignored\n2.5\n",
+		},
+		{
+			`program procedures;
+var counter: integer := 0;
+procedure increment(by: integer);
+	var half: real;
+	begin
+		half := by / 2;
+		counter := counter + by;
+		writeln(half)
+	end;
+procedure twice(by: integer);
+	begin
+		increment(by);
+		increment(by)
+	end;
+begin
+	twice(3);
+	writeln(counter)
+end.`,
+			"",
+		},
+		{
+			`program shadowing;
+var x: integer := 1;
+procedure show(x: real);
+	begin
+		writeln(x)
+	end;
+begin
+	show(x + 1);
+	writeln(x)
+end.`,
+			"",
+		},
+		{
+			`program recursion;
+var n: integer;
+var result: integer := 1;
+procedure factorial(k: integer);
+	begin
+		if k > 1 then
+			result := result * k;
+			factorial(k - 1)
+		end
+	end;
+procedure countdown(k: integer);
+	var i: integer;
+	begin
+		for i := 1 to k do
+			write(k - i);
+		writeln
+	end;
+begin
+	read(n);
+	factorial(n);
+	writeln(result);
+	countdown(n)
+end.`,
+			"6",
+		},
+		{
+			`program strings;
+var x: real := 1.5;
+begin
+	writeln('x = ', x, ', it''s ', x > 1);
+	write('''', '')
+end.`,
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		expected := testEval(t, tt.input, tt.stdin)
+		_, output, err := testRun(t, tt.input, tt.stdin)
+
+		if nil != err {
+			t.Fatalf("vm error for %q: %s", tt.input, err)
+		}
+
+		if output != expected {
+			t.Errorf("vm output differs from the evaluator, expected=%q, got=%q", expected, output)
+		}
+	}
+}